@@ -0,0 +1,209 @@
+package src
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRedirects is the number of redirects followed, as in net/http.
+const maxRedirects = 10
+
+// credentials are the static headers and authentication applied to requests.
+type credentials struct {
+	headers http.Header
+	hosts   map[string]bool
+
+	// configured is set when auth_hosts lists the hosts. Otherwise the hosts
+	// are those of the sitemaps or start URLs, added with AuthorizeHosts.
+	configured bool
+}
+
+// resolveCredentials builds the request headers from the config, reading
+// secrets from the environment where configured. Header values may reference
+// environment variables as $VAR or ${VAR}, and "$$" stands for a literal "$".
+func resolveCredentials(cfg HTTPConfig) (*credentials, error) {
+	headers := make(http.Header)
+	for name, value := range cfg.Headers {
+		expanded, err := expandEnv(value)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		headers.Set(name, expanded)
+	}
+
+	if cfg.BasicAuth != nil {
+		username, err := secret(cfg.BasicAuth.Username, cfg.BasicAuth.UsernameEnv)
+		if err != nil {
+			return nil, fmt.Errorf("basic auth username: %w", err)
+		}
+		password, err := secret(cfg.BasicAuth.Password, cfg.BasicAuth.PasswordEnv)
+		if err != nil {
+			return nil, fmt.Errorf("basic auth password: %w", err)
+		}
+		req := &http.Request{Header: make(http.Header)}
+		req.SetBasicAuth(username, password)
+		headers.Set("Authorization", req.Header.Get("Authorization"))
+	}
+
+	if cfg.BearerToken != "" || cfg.BearerTokenEnv != "" {
+		token, err := secret(cfg.BearerToken, cfg.BearerTokenEnv)
+		if err != nil {
+			return nil, fmt.Errorf("bearer token: %w", err)
+		}
+		headers.Set("Authorization", "Bearer "+token)
+	}
+
+	creds := &credentials{headers: headers, hosts: make(map[string]bool), configured: len(cfg.AuthHosts) > 0}
+	for _, host := range cfg.AuthHosts {
+		creds.hosts[strings.ToLower(host)] = true
+	}
+
+	return creds, nil
+}
+
+// expandEnv replaces $VAR and ${VAR} in value with the environment variable.
+// "$$" is a literal "$". A variable that is not set is an error.
+func expandEnv(value string) (string, error) {
+	var missing []string
+	expanded := os.Expand(value, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		envValue, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return envValue
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// AuthorizeHosts allows the configured headers and credentials to be sent to
+// the hosts of urls. It has no effect when auth_hosts lists the hosts.
+func (c *Client) AuthorizeHosts(urls []string) {
+	if c.credentials.configured {
+		return
+	}
+	for _, rawURL := range urls {
+		if u, err := url.Parse(rawURL); err == nil && u.Hostname() != "" {
+			c.credentials.hosts[strings.ToLower(u.Hostname())] = true
+		}
+	}
+}
+
+// secret returns value, or the content of the environment variable envName
+// when one is configured.
+func secret(value, envName string) (string, error) {
+	if envName == "" {
+		return value, nil
+	}
+	envValue, ok := os.LookupEnv(envName)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", envName)
+	}
+	return envValue, nil
+}
+
+// apply adds the headers to req if it goes to one of the allowed hosts.
+func (c *credentials) apply(req *http.Request) {
+	if c == nil || len(c.headers) == 0 || !c.hosts[strings.ToLower(req.URL.Hostname())] {
+		return
+	}
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+}
+
+// checkRedirect applies the host check to a redirect target. Go copies the
+// headers of the first request to redirects and only drops Authorization
+// and cookies when the host changes, so custom headers are removed here.
+func (c *credentials) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if c != nil {
+		for name := range c.headers {
+			req.Header.Del(name)
+		}
+		c.apply(req)
+	}
+	return nil
+}
+
+// loadCookieJar creates a cookie jar seeded from a Netscape cookies.txt file,
+// as exported by browsers and used by curl and wget.
+func loadCookieJar(path string) (http.CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cookies file: %w", err)
+	}
+	defer file.Close()
+
+	now := time.Now()
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		httpOnly := false
+		if rest, ok := strings.CutPrefix(line, "#HttpOnly_"); ok {
+			line = rest
+			httpOnly = true
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookies file line %d: expected 7 tab-separated fields, got %d", lineNumber, len(fields))
+		}
+
+		domain := fields[0]
+		includeSubdomains := strings.EqualFold(fields[1], "TRUE")
+		secure := strings.EqualFold(fields[3], "TRUE")
+
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   secure,
+			HttpOnly: httpOnly,
+		}
+		if includeSubdomains {
+			cookie.Domain = domain
+		}
+
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+			if cookie.Expires.Before(now) {
+				continue
+			}
+		}
+
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: strings.TrimPrefix(domain, "."), Path: cookie.Path}, []*http.Cookie{cookie})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cookies file: %w", err)
+	}
+
+	return jar, nil
+}
@@ -0,0 +1,73 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCredentialsRedirect(t *testing.T) {
+	var targetHeaders http.Header
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targetHeaders = r.Header.Clone()
+	}))
+	defer target.Close()
+
+	var sourceHeaders http.Header
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sourceHeaders = r.Header.Clone()
+		// Same server, but a host name that is not authorized.
+		http.Redirect(w, r, strings.Replace(target.URL, "127.0.0.1", "localhost", 1)+"/next", http.StatusFound)
+	}))
+	defer source.Close()
+
+	client, err := NewClient(HTTPConfig{Headers: map[string]string{"X-Api-Key": "secret"}, BearerToken: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.AuthorizeHosts([]string{source.URL})
+
+	resp, err := client.Get(source.URL + "/start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := sourceHeaders.Get("X-Api-Key"); got != "secret" {
+		t.Errorf("authorized host got X-Api-Key %q, want %q", got, "secret")
+	}
+	if got := sourceHeaders.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("authorized host got Authorization %q, want %q", got, "Bearer token")
+	}
+	if targetHeaders == nil {
+		t.Fatal("redirect was not followed")
+	}
+	for _, name := range []string{"X-Api-Key", "Authorization"} {
+		if got := targetHeaders.Get(name); got != "" {
+			t.Errorf("redirect target got %s %q, want none", name, got)
+		}
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("DOCS_API_KEY", "secret")
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "${DOCS_API_KEY}", want: "secret"},
+		{value: "key=$DOCS_API_KEY;", want: "key=secret;"},
+		{value: "pa$$word", want: "pa$word"},
+		{value: "costs 5$", want: "costs 5$"},
+		{value: "${MEILISEARCH_SCRAPER_UNSET}", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := expandEnv(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("expandEnv(%q) = %q, %v; want %q, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Upload batch defaults, used when the corresponding UploadConfig field is
+// zero. Meilisearch rejects payloads above 100 MB by default.
+const (
+	DefaultBatchSize  = 1000
+	DefaultBatchBytes = 10 << 20
+)
+
+// Validate checks the batch limits.
+func (u UploadConfig) Validate() error {
+	if u.BatchSize < 0 || u.BatchBytes < 0 {
+		return fmt.Errorf("upload: batch limits must not be negative")
+	}
+	return nil
+}
+
+// Batcher groups documents into upload batches of at most maxDocuments
+// documents and maxBytes bytes of JSON, handing each full batch to flush. A
+// single document larger than maxBytes is sent in a batch of its own.
+type Batcher struct {
+	maxDocuments int
+	maxBytes     int
+	flush        func([]json.RawMessage)
+
+	documents []json.RawMessage
+	size      int
+}
+
+func NewBatcher(maxDocuments, maxBytes int, flush func([]json.RawMessage)) *Batcher {
+	if maxDocuments <= 0 {
+		maxDocuments = DefaultBatchSize
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultBatchBytes
+	}
+	return &Batcher{maxDocuments: maxDocuments, maxBytes: maxBytes, flush: flush}
+}
+
+// Add encodes a document and adds it to the current batch, flushing the batch
+// first if the document would not fit.
+func (b *Batcher) Add(doc Document) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode document %s: %w", doc.ObjectID, err)
+	}
+
+	// b.size is the length of the JSON array: brackets, documents and the
+	// commas between them.
+	if len(b.documents) > 0 && b.size+1+len(data) > b.maxBytes {
+		b.Flush()
+	}
+	if len(b.documents) == 0 {
+		b.size = 2 + len(data)
+	} else {
+		b.size += 1 + len(data)
+	}
+	b.documents = append(b.documents, data)
+
+	if len(b.documents) >= b.maxDocuments {
+		b.Flush()
+	}
+	return nil
+}
+
+// Flush hands the current batch to flush, if it holds any documents.
+func (b *Batcher) Flush() {
+	if len(b.documents) == 0 {
+		return
+	}
+	batch := b.documents
+	b.documents = nil
+	b.size = 0
+	b.flush(batch)
+}
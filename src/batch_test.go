@@ -0,0 +1,86 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBatcherAdd(t *testing.T) {
+	// Every test document encodes to the same number of bytes.
+	newDoc := func(i int) Document {
+		return newDocument(testPageURL, fmt.Sprintf("a%03d", i), hierarchy{}, "text", "")
+	}
+	docSize := func() int {
+		data, err := json.Marshal(newDoc(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(data)
+	}()
+
+	tests := []struct {
+		name         string
+		maxDocuments int
+		maxBytes     int
+		documents    int
+		want         []int
+	}{
+		{"fewer than a batch", 10, 0, 3, []int{3}},
+		{"split by document count", 2, 0, 5, []int{2, 2, 1}},
+		{"exact multiple", 2, 0, 4, []int{2, 2}},
+		{"split by size", 10, 2 + 3*docSize + 2, 7, []int{3, 3, 1}},
+		{"size limit one byte short", 10, 2 + 3*docSize + 1, 6, []int{2, 2, 2}},
+		{"document larger than the limit", 10, docSize, 3, []int{1, 1, 1}},
+		{"defaults", 0, 0, 1001, []int{1000, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var batches [][]json.RawMessage
+			batcher := NewBatcher(tt.maxDocuments, tt.maxBytes, func(batch []json.RawMessage) {
+				batches = append(batches, batch)
+			})
+			for i := 0; i < tt.documents; i++ {
+				if err := batcher.Add(newDoc(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			batcher.Flush()
+
+			var sizes []int
+			next := 0
+			for _, batch := range batches {
+				sizes = append(sizes, len(batch))
+
+				data, err := json.Marshal(batch)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.maxBytes > 0 && len(batch) > 1 && len(data) > tt.maxBytes {
+					t.Errorf("batch of %d documents is %d bytes, over the limit of %d", len(batch), len(data), tt.maxBytes)
+				}
+				for _, raw := range batch {
+					if want := fmt.Sprintf(`"anchor":"a%03d"`, next); !strings.Contains(string(raw), want) {
+						t.Errorf("document %d out of order: %s", next, raw)
+					}
+					next++
+				}
+			}
+			if !reflect.DeepEqual(sizes, tt.want) {
+				t.Errorf("got batch sizes %v, want %v", sizes, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatcherFlushEmpty(t *testing.T) {
+	flushed := false
+	batcher := NewBatcher(0, 0, func([]json.RawMessage) { flushed = true })
+	batcher.Flush()
+	if flushed {
+		t.Error("flushing an empty batcher sent a batch")
+	}
+}
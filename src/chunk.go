@@ -0,0 +1,162 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Validate checks that the chunking limits are consistent.
+func (c ChunkingConfig) Validate() error {
+	if c.MaxChars < 0 || c.MaxTokens < 0 || c.Overlap < 0 {
+		return fmt.Errorf("chunking: limits must not be negative")
+	}
+	if limit := c.limit(); limit > 0 && c.Overlap >= limit {
+		return fmt.Errorf("chunking: overlap must be smaller than the chunk size")
+	}
+	return nil
+}
+
+// limit returns the maximum chunk size, in tokens when max_tokens is set and
+// in characters otherwise. Zero disables chunking.
+func (c ChunkingConfig) limit() int {
+	if c.MaxTokens > 0 {
+		return c.MaxTokens
+	}
+	return c.MaxChars
+}
+
+// size measures text in the unit of the limit. Tokens are approximated by
+// whitespace-separated words.
+func (c ChunkingConfig) size(text string) int {
+	if c.MaxTokens > 0 {
+		return len(strings.Fields(text))
+	}
+	return utf8.RuneCountInString(text)
+}
+
+// sizeOf measures units joined by single spaces.
+func (c ChunkingConfig) sizeOf(units []string) int {
+	if len(units) == 0 {
+		return 0
+	}
+	if c.MaxTokens > 0 {
+		return c.size(strings.Join(units, " "))
+	}
+	total := len(units) - 1
+	for _, unit := range units {
+		total += c.size(unit)
+	}
+	return total
+}
+
+// Chunk splits content parts (paragraphs or text nodes) into chunks of at
+// most the configured size. Parts are kept whole when they fit; longer parts
+// are split at sentence boundaries, and overlong sentences at word
+// boundaries. Each chunk after the first starts with up to Overlap of the
+// trailing text of the previous chunk.
+func (c ChunkingConfig) Chunk(parts []string) []string {
+	limit := c.limit()
+	if limit <= 0 {
+		return []string{strings.Join(parts, " ")}
+	}
+
+	var units []string
+	for _, part := range parts {
+		units = append(units, c.splitUnit(part, limit)...)
+	}
+
+	var chunks []string
+	var current []string
+	for _, unit := range units {
+		if len(current) > 0 && c.sizeOf(append(current, unit)) > limit {
+			chunks = append(chunks, strings.Join(current, " "))
+			current = c.overlapTail(current, unit, limit)
+		}
+		current = append(current, unit)
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, strings.Join(current, " "))
+	}
+
+	return chunks
+}
+
+// overlapTail returns the trailing units of a finished chunk that are
+// repeated at the start of the next one.
+func (c ChunkingConfig) overlapTail(units []string, next string, limit int) []string {
+	if c.Overlap <= 0 {
+		return nil
+	}
+
+	start := len(units)
+	for start > 0 && c.sizeOf(units[start-1:]) <= c.Overlap {
+		start--
+	}
+	tail := append([]string(nil), units[start:]...)
+
+	for len(tail) > 0 && c.sizeOf(append(tail, next)) > limit {
+		tail = tail[1:]
+	}
+	return tail
+}
+
+// splitUnit breaks a part that exceeds the limit into sentences, and those
+// into word runs.
+func (c ChunkingConfig) splitUnit(part string, limit int) []string {
+	if c.size(part) <= limit {
+		return []string{part}
+	}
+
+	var units []string
+	for _, sentence := range splitSentences(part) {
+		if c.size(sentence) <= limit {
+			units = append(units, sentence)
+			continue
+		}
+		units = append(units, c.splitWords(sentence, limit)...)
+	}
+	return units
+}
+
+func (c ChunkingConfig) splitWords(text string, limit int) []string {
+	var pieces []string
+	var current []string
+	for _, word := range strings.Fields(text) {
+		// A single word longer than the limit can only be cut.
+		for c.size(word) > limit {
+			runes := []rune(word)
+			pieces = append(pieces, string(runes[:limit]))
+			word = string(runes[limit:])
+		}
+		if len(current) > 0 && c.sizeOf(append(current, word)) > limit {
+			pieces = append(pieces, strings.Join(current, " "))
+			current = nil
+		}
+		current = append(current, word)
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, strings.Join(current, " "))
+	}
+	return pieces
+}
+
+// splitSentences splits text after ".", "!" or "?" followed by whitespace.
+func splitSentences(text string) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	for i := 0; i < len(runes)-1; i++ {
+		if strings.ContainsRune(".!?", runes[i]) && unicode.IsSpace(runes[i+1]) {
+			if sentence := strings.TrimSpace(string(runes[start : i+1])); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(string(runes[start:])); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
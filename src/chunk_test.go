@@ -0,0 +1,118 @@
+package src
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ChunkingConfig
+		parts  []string
+		want   []string
+	}{
+		{
+			name:   "no limit joins the parts",
+			config: ChunkingConfig{},
+			parts:  []string{"First.", "Second."},
+			want:   []string{"First. Second."},
+		},
+		{
+			name:   "content that fits is one chunk",
+			config: ChunkingConfig{MaxChars: 20},
+			parts:  []string{"Hello world.", "Bye."},
+			want:   []string{"Hello world. Bye."},
+		},
+		{
+			name:   "parts are kept whole",
+			config: ChunkingConfig{MaxChars: 10},
+			parts:  []string{"aaaa bbbb", "cccc dddd"},
+			want:   []string{"aaaa bbbb", "cccc dddd"},
+		},
+		{
+			name:   "overlap repeats trailing parts",
+			config: ChunkingConfig{MaxChars: 12, Overlap: 5},
+			parts:  []string{"one.", "two.", "three.", "four."},
+			want:   []string{"one. two.", "two. three.", "four."},
+		},
+		{
+			name:   "overlap that does not fit with the next part is dropped",
+			config: ChunkingConfig{MaxChars: 10, Overlap: 4},
+			parts:  []string{"abc.", "defgh.", "ijklmnop."},
+			want:   []string{"abc.", "defgh.", "ijklmnop."},
+		},
+		{
+			name:   "long parts are split into sentences",
+			config: ChunkingConfig{MaxChars: 20},
+			parts:  []string{"First sentence here. Second one is here."},
+			want:   []string{"First sentence here.", "Second one is here."},
+		},
+		{
+			name:   "long sentences are split at words",
+			config: ChunkingConfig{MaxChars: 11},
+			parts:  []string{"alpha beta gamma delta"},
+			want:   []string{"alpha beta", "gamma delta"},
+		},
+		{
+			name:   "overlong words are cut",
+			config: ChunkingConfig{MaxChars: 4},
+			parts:  []string{"abcdefghij"},
+			want:   []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name:   "characters are counted, not bytes",
+			config: ChunkingConfig{MaxChars: 3},
+			parts:  []string{"ééééé"},
+			want:   []string{"ééé", "éé"},
+		},
+		{
+			name:   "max tokens counts words",
+			config: ChunkingConfig{MaxTokens: 3, Overlap: 1},
+			parts:  []string{"a b.", "c.", "d e."},
+			want:   []string{"a b. c.", "c. d e."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.config.Chunk(tt.parts)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chunk(%q) = %q, want %q", tt.parts, got, tt.want)
+			}
+			if limit := tt.config.limit(); limit > 0 {
+				for _, chunk := range got {
+					if size := tt.config.size(chunk); size > limit {
+						t.Errorf("chunk %q has size %d, over the limit %d", chunk, size, limit)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestChunkingConfigValidate(t *testing.T) {
+	tests := []struct {
+		config  ChunkingConfig
+		wantErr bool
+	}{
+		{ChunkingConfig{}, false},
+		{ChunkingConfig{MaxChars: 100, Overlap: 20}, false},
+		{ChunkingConfig{MaxChars: 100, Overlap: 100}, true},
+		{ChunkingConfig{MaxTokens: 10, MaxChars: 1000, Overlap: 10}, true},
+		{ChunkingConfig{MaxChars: -1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: Validate() error = %v, wantErr %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	got := splitSentences("Version 1.2 is out! Is it stable? Yes.  Mostly")
+	want := []string{"Version 1.2 is out!", "Is it stable?", "Yes.", "Mostly"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitSentences() = %q, want %q", got, want)
+	}
+}
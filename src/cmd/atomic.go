@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jansaidl/meilisearch-scraper/src"
+	"github.com/meilisearch/meilisearch-go"
+	"github.com/spf13/cobra"
+)
+
+// atomicReindex replaces the live index without downtime: the documents go
+// into a temporary index, which is checked against the document count
+// thresholds and then swapped with the live index. The temporary index,
+// holding the old documents after the swap, is deleted.
+type atomicReindex struct {
+	tmpName       string
+	liveExists    bool
+	liveDocuments int64
+}
+
+// prepareAtomic creates the temporary index with the settings the live index
+// should end up with.
+func prepareAtomic(cmd *cobra.Command, client meilisearch.ServiceManager, indexName string, config *src.Config) *atomicReindex {
+	liveExists, err := indexExists(client, indexName)
+	if err != nil {
+		log.Fatalf("Failed to look up index %s: %v", indexName, err)
+	}
+	reindex := &atomicReindex{
+		tmpName:    fmt.Sprintf("%s_tmp_%d", indexName, time.Now().Unix()),
+		liveExists: liveExists,
+	}
+	if liveExists {
+		stats, err := client.Index(indexName).GetStats()
+		if err != nil {
+			log.Fatalf("Failed to get stats of index %s: %v", indexName, err)
+		}
+		reindex.liveDocuments = stats.NumberOfDocuments
+	}
+
+	tmpName := reindex.tmpName
+	log.Printf("Creating temporary index: %s", tmpName)
+	task, err := client.CreateIndex(&meilisearch.IndexConfig{Uid: tmpName, PrimaryKey: "objectID"})
+	if err != nil {
+		log.Fatalf("Failed to create index %s: %v", tmpName, err)
+	}
+	if err := waitForTask(cmd, client, task, "create index"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Settings are swapped along with the documents, so the temporary index
+	// needs the settings the live index should end up with.
+	if skip, _ := cmd.Flags().GetBool("skip-settings"); !skip {
+		if err := applySettings(cmd, client, tmpName, config); err != nil {
+			abortAtomic(client, tmpName, "%v", err)
+		}
+	} else if liveExists {
+		settings, err := client.Index(indexName).GetSettings()
+		if err != nil {
+			abortAtomic(client, tmpName, "Failed to get settings of index %s: %v", indexName, err)
+		}
+		task, err := client.Index(tmpName).UpdateSettings(settings)
+		if err != nil {
+			abortAtomic(client, tmpName, "Failed to copy settings: %v", err)
+		}
+		log.Printf("Copying settings of %s, task ID: %d", indexName, task.TaskUID)
+		if err := waitForTask(cmd, client, task, "copy settings"); err != nil {
+			abortAtomic(client, tmpName, "%v", err)
+		}
+	}
+
+	return reindex
+}
+
+// finish checks the uploaded temporary index against the thresholds and
+// swaps it with the live index.
+func (r *atomicReindex) finish(cmd *cobra.Command, client meilisearch.ServiceManager, indexName string) {
+	minDocuments, _ := cmd.Flags().GetInt64("min-documents")
+	minRatio, _ := cmd.Flags().GetFloat64("min-ratio")
+	tmpName := r.tmpName
+
+	stats, err := client.Index(tmpName).GetStats()
+	if err != nil {
+		abortAtomic(client, tmpName, "Failed to get stats of index %s: %v", tmpName, err)
+	}
+	count := stats.NumberOfDocuments
+	log.Printf("Temporary index holds %d documents (live index: %d)", count, r.liveDocuments)
+
+	if count < minDocuments {
+		abortAtomic(client, tmpName, "Temporary index holds %d documents, fewer than --min-documents %d", count, minDocuments)
+	}
+	if minRatio > 0 && float64(count) < minRatio*float64(r.liveDocuments) {
+		abortAtomic(client, tmpName, "Temporary index holds %d documents, fewer than %.0f%% of the %d live documents", count, minRatio*100, r.liveDocuments)
+	}
+
+	// Swapping needs both indexes to exist.
+	if !r.liveExists {
+		task, err := client.CreateIndex(&meilisearch.IndexConfig{Uid: indexName, PrimaryKey: "objectID"})
+		if err != nil {
+			abortAtomic(client, tmpName, "Failed to create index %s: %v", indexName, err)
+		}
+		if err := waitForTask(cmd, client, task, "create index"); err != nil {
+			abortAtomic(client, tmpName, "%v", err)
+		}
+	}
+
+	log.Printf("Swapping %s with %s", indexName, tmpName)
+	task, err := client.SwapIndexes([]*meilisearch.SwapIndexesParams{{Indexes: []string{indexName, tmpName}}})
+	if err != nil {
+		abortAtomic(client, tmpName, "Failed to swap indexes: %v", err)
+	}
+	if err := waitForTask(cmd, client, task, "swap indexes"); err != nil {
+		abortAtomic(client, tmpName, "%v", err)
+	}
+
+	log.Printf("Deleting previous index data: %s", tmpName)
+	task, err = client.DeleteIndex(tmpName)
+	if err != nil {
+		log.Fatalf("Failed to delete index %s: %v", tmpName, err)
+	}
+	if err := waitForTask(cmd, client, task, "delete index"); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// abortAtomic deletes the temporary index and exits with the given message,
+// leaving the live index untouched.
+func abortAtomic(client meilisearch.ServiceManager, tmpName string, format string, args ...interface{}) {
+	log.Printf(format, args...)
+	log.Printf("Deleting temporary index %s; the live index is unchanged", tmpName)
+	if _, err := client.DeleteIndex(tmpName); err != nil {
+		log.Printf("Failed to delete index %s: %v", tmpName, err)
+	}
+	log.Fatal("Atomic reindex aborted")
+}
+
+// indexExists reports whether an index exists.
+func indexExists(client meilisearch.ServiceManager, indexName string) (bool, error) {
+	_, err := client.GetIndex(indexName)
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// isNotFound reports whether a Meilisearch request failed because the index
+// or document does not exist.
+func isNotFound(err error) bool {
+	var meiliErr *meilisearch.Error
+	return errors.As(err, &meiliErr) && meiliErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,387 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jansaidl/meilisearch-scraper/src"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configPath returns the path of the scraper config file.
+func configPath() string {
+	configPath := viper.GetString("config")
+	if configPath == "" {
+		configPath = "config.json"
+	}
+	return configPath
+}
+
+// loadConfig reads and parses the scraper config file.
+func loadConfig() src.Config {
+	configPath := configPath()
+
+	configFile, err := os.ReadFile(configPath)
+	if err != nil {
+		log.Fatalf("Failed to read config file %s: %v", configPath, err)
+	}
+
+	config, warnings, err := src.ParseConfig(configFile)
+	if err != nil {
+		log.Fatalf("Failed to parse config file: %v", err)
+	}
+	if config.Format == src.FormatDocSearch {
+		log.Printf("Imported DocSearch config %s", configPath)
+	}
+	for _, warning := range warnings {
+		log.Printf("WARNING: DocSearch config: %s", warning)
+	}
+
+	if err := config.Validate(); err != nil {
+		log.Fatalf("Invalid config: %v", err)
+	}
+
+	client, err := src.NewClient(config.HTTP)
+	if err != nil {
+		log.Fatalf("Invalid HTTP config: %v", err)
+	}
+	src.DefaultClient = client
+
+	return *config
+}
+
+// loadOptionalConfig is like loadConfig but falls back to the defaults when
+// the config file does not exist.
+func loadOptionalConfig() src.Config {
+	if _, err := os.Stat(configPath()); errors.Is(err, os.ErrNotExist) {
+		return src.Config{}
+	}
+	return loadConfig()
+}
+
+// addScrapeFlags registers the flags controlling which URLs are scraped and
+// how they are fetched.
+func addScrapeFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("limit", 0, "Limit number of URLs to process (0 = no limit)")
+	cmd.Flags().String("since", "", "Only process URLs whose sitemap lastmod is on or after this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().StringSlice("crawl", nil, "Crawl by following links from these start URLs instead of reading a sitemap")
+	cmd.Flags().StringSlice("allow-prefix", nil, "Only follow links under these URL prefixes when crawling (default: start URL hosts)")
+	cmd.Flags().Int("max-depth", 0, "Maximum link depth when crawling (0 = no limit)")
+	cmd.Flags().Int("max-pages", 0, "Maximum number of pages to discover when crawling (0 = no limit)")
+	cmd.Flags().StringArray("include", nil, "Only scrape URLs matching this glob or regex: pattern (repeatable)")
+	cmd.Flags().StringArray("exclude", nil, "Skip URLs matching this glob or regex: pattern (repeatable)")
+	cmd.Flags().Bool("ignore-robots", false, "Do not honour robots.txt rules and crawl delays")
+	cmd.Flags().Int("concurrency", 0, "Number of pages scraped in parallel (default 4)")
+	cmd.Flags().Float64("rate-limit", 0, "Maximum requests per second per host (default 5, negative = unlimited)")
+}
+
+// indexName returns the Meilisearch index to use. An index name from an
+// imported config only applies when none was given explicitly.
+func indexName(config *src.Config) string {
+	if !viper.IsSet("meilisearch.index") && config.IndexName != "" {
+		return config.IndexName
+	}
+	indexName := viper.GetString("meilisearch.index")
+	if indexName == "" {
+		indexName = "docs"
+	}
+	return indexName
+}
+
+// newRobotsCache returns the robots.txt policy for a scrape, or nil when
+// robots.txt is ignored.
+func newRobotsCache(cmd *cobra.Command, config *src.Config) *src.RobotsCache {
+	ignore, _ := cmd.Flags().GetBool("ignore-robots")
+	if ignore || config.Robots.Ignore {
+		log.Println("Ignoring robots.txt")
+		return nil
+	}
+	userAgent := config.Robots.UserAgent
+	if userAgent == "" {
+		userAgent = config.HTTP.UserAgent
+	}
+	return src.NewRobotsCache(userAgent)
+}
+
+// newHostLimiter returns the per-host rate limiter for a scrape.
+func newHostLimiter(cmd *cobra.Command, config *src.Config, robots *src.RobotsCache) *src.HostLimiter {
+	rateLimit := config.RateLimit
+	if cmd.Flags().Changed("rate-limit") {
+		rateLimit, _ = cmd.Flags().GetFloat64("rate-limit")
+	}
+	if rateLimit == 0 {
+		rateLimit = src.DefaultRateLimit
+	}
+	return src.NewHostLimiter(rateLimit, robots)
+}
+
+// scrapeConcurrency returns the number of pages scraped in parallel.
+func scrapeConcurrency(cmd *cobra.Command, config *src.Config) int {
+	concurrency := config.Concurrency
+	if cmd.Flags().Changed("concurrency") {
+		concurrency, _ = cmd.Flags().GetInt("concurrency")
+	}
+	if concurrency <= 0 {
+		concurrency = src.DefaultConcurrency
+	}
+	return concurrency
+}
+
+// scrapeURLs scrapes urls with the configured concurrency, passing the
+// documents of each page to handle in URL order. It returns the number of
+// documents and the URLs that failed to scrape.
+func scrapeURLs(cmd *cobra.Command, config *src.Config, urls []src.URL, limiter *src.HostLimiter, handle func([]src.Document)) (int, []string) {
+	concurrency := scrapeConcurrency(cmd, config)
+
+	log.Printf("Scraping %d URLs with %d workers", len(urls), concurrency)
+
+	var count int
+	var failed []string
+	pagesPerSet := make(map[string]int)
+	src.ScrapeAll(urls, config, src.ScrapeOptions{Concurrency: concurrency, Limiter: limiter}, func(result src.ScrapeResult) {
+		if result.Err != nil {
+			log.Printf("Failed to scrape %s: %v", result.URL.Loc, result.Err)
+			failed = append(failed, result.URL.Loc)
+			return
+		}
+		log.Printf("Scraped %s: %d documents (selector set %q)", result.URL.Loc, len(result.Documents), result.SelectorSet)
+		pagesPerSet[result.SelectorSet]++
+		count += len(result.Documents)
+		handle(result.Documents)
+	})
+
+	logSelectorSets(config, pagesPerSet)
+	return count, failed
+}
+
+// logSelectorSets logs how many pages each selector set was used for.
+func logSelectorSets(config *src.Config, pagesPerSet map[string]int) {
+	if len(config.SelectorSets) > 0 {
+		for _, name := range sortedKeys(pagesPerSet) {
+			log.Printf("Selector set %q: %d pages", name, pagesPerSet[name])
+		}
+	}
+}
+
+// scrapeReport describes the pages scraped by a run.
+type scrapeReport struct {
+	// URLs are the pages that were scraped, including those that failed.
+	URLs      []src.URL
+	Failed    []string
+	Documents int
+
+	// Unreachable lists the sitemaps, or when crawling the pages, that could
+	// not be fetched, so that the pages they lead to are missing from URLs.
+	Unreachable []string
+	// Crawl is the crawl configuration when the pages were found by crawling.
+	Crawl *src.CrawlConfig
+}
+
+// pageSource is where the pages of a run come from: the URLs read from the
+// sitemaps, or a crawl that finds the pages while scraping them.
+type pageSource struct {
+	urls           []src.URL
+	failedSitemaps []string
+	crawl          *src.CrawlConfig
+	robots         *src.RobotsCache
+	limiter        *src.HostLimiter
+}
+
+// discoverPages reads the sitemaps, or prepares the crawl when crawling.
+func discoverPages(cmd *cobra.Command, args []string, config *src.Config) *pageSource {
+	robots := newRobotsCache(cmd, config)
+	source := &pageSource{robots: robots, limiter: newHostLimiter(cmd, config, robots)}
+
+	sitemapURLs, crawl := pageSources(cmd, args, config)
+	src.DefaultClient.AuthorizeHosts(append(append([]string{}, sitemapURLs...), crawl.StartURLs...))
+	if len(sitemapURLs) == 0 {
+		source.crawl = &crawl
+		return source
+	}
+	source.urls, source.failedSitemaps = discoverURLs(cmd, config, sitemapURLs, robots)
+	return source
+}
+
+// scrape scrapes the pages, passing the documents of each page to handle.
+func (p *pageSource) scrape(cmd *cobra.Command, config *src.Config, handle func([]src.Document)) (*scrapeReport, error) {
+	if p.crawl != nil {
+		return crawlSite(cmd, config, *p.crawl, p.robots, p.limiter, handle)
+	}
+	count, failed := scrapeURLs(cmd, config, p.urls, p.limiter, handle)
+	return &scrapeReport{URLs: p.urls, Failed: failed, Documents: count, Unreachable: p.failedSitemaps}, nil
+}
+
+// pageSources returns the sitemaps to read, given as argument, SITEMAP_URL or
+// in the config file, or else the crawl configuration with the start URLs
+// given with --crawl or in the config file.
+func pageSources(cmd *cobra.Command, args []string, config *src.Config) ([]string, src.CrawlConfig) {
+	var sitemapURLs []string
+	if len(args) > 0 {
+		sitemapURLs = []string{args[0]}
+	} else if sitemapURL := viper.GetString("sitemap.url"); sitemapURL != "" {
+		sitemapURLs = []string{sitemapURL}
+	} else {
+		sitemapURLs = config.Sitemaps
+	}
+
+	crawl := config.Crawl
+	if startURLs, _ := cmd.Flags().GetStringSlice("crawl"); len(startURLs) > 0 {
+		crawl.StartURLs = startURLs
+		sitemapURLs = nil
+	}
+	if prefixes, _ := cmd.Flags().GetStringSlice("allow-prefix"); len(prefixes) > 0 {
+		crawl.AllowedPrefixes = prefixes
+	}
+	if cmd.Flags().Changed("max-depth") {
+		crawl.MaxDepth, _ = cmd.Flags().GetInt("max-depth")
+	}
+	if cmd.Flags().Changed("max-pages") {
+		crawl.MaxPages, _ = cmd.Flags().GetInt("max-pages")
+	}
+
+	if len(sitemapURLs) == 0 && len(crawl.StartURLs) == 0 {
+		log.Fatal("Sitemap URL or crawl start URLs are required (use argument, SITEMAP_URL env variable, --crawl, or sitemaps or crawl.start_urls in config)")
+	}
+	return sitemapURLs, crawl
+}
+
+// discoverURLs returns the URLs to scrape from the sitemaps, and the sitemaps
+// that could not be fetched. A site root is resolved to the sitemaps listed
+// in its robots.txt. URLs disallowed by robots.txt are dropped.
+func discoverURLs(cmd *cobra.Command, config *src.Config, sitemapURLs []string, robots *src.RobotsCache) ([]src.URL, []string) {
+	var resolved []string
+	for _, sitemapURL := range sitemapURLs {
+		if !src.IsSiteRoot(sitemapURL) {
+			resolved = append(resolved, sitemapURL)
+			continue
+		}
+		discovered := robots.DiscoverSitemaps(sitemapURL)
+		log.Printf("Discovered sitemaps for %s: %v", sitemapURL, discovered)
+		resolved = append(resolved, discovered...)
+	}
+	sitemapURLs = resolved
+
+	log.Printf("Fetching sitemap: %s", strings.Join(sitemapURLs, ", "))
+
+	sitemap, err := src.FetchSitemaps(sitemapURLs)
+	if err != nil {
+		log.Fatalf("Failed to fetch sitemap: %v", err)
+	}
+	if len(sitemap.Failed) > 0 {
+		log.Printf("WARNING: %d sitemaps could not be fetched: %s", len(sitemap.Failed), strings.Join(sitemap.Failed, ", "))
+	}
+	log.Printf("Found %d URLs in sitemap", len(sitemap.URLs))
+	urls := sitemap.URLs
+
+	if robots != nil {
+		allowed := robots.Filter(urls)
+		if skipped := len(urls) - len(allowed); skipped > 0 {
+			log.Printf("Skipping %d URLs disallowed by robots.txt", skipped)
+		}
+		urls = allowed
+	}
+
+	urls = filterURLs(cmd, config, urls)
+
+	if since, _ := cmd.Flags().GetString("since"); since != "" {
+		sinceTime, err := src.ParseLastMod(since)
+		if err != nil {
+			log.Fatalf("Invalid --since value: %v", err)
+		}
+		urls = src.FilterModifiedSince(urls, sinceTime)
+		log.Printf("%d URLs modified since %s", len(urls), since)
+	}
+
+	if limit, _ := cmd.Flags().GetInt("limit"); limit > 0 && limit < len(urls) {
+		urls = urls[:limit]
+		log.Printf("Limiting to %d URLs", limit)
+	}
+
+	return urls, sitemap.Failed
+}
+
+// crawlSite crawls from the start URLs and scrapes every page found, applying
+// the URL rules and --limit while crawling. Crawled pages have no lastmod, so
+// --since does not apply.
+func crawlSite(cmd *cobra.Command, config *src.Config, crawl src.CrawlConfig, robots *src.RobotsCache, limiter *src.HostLimiter, handle func([]src.Document)) (*scrapeReport, error) {
+	filter := urlFilter(cmd, config)
+	if filter.Empty() {
+		filter = nil
+	}
+	limit, _ := cmd.Flags().GetInt("limit")
+	concurrency := scrapeConcurrency(cmd, config)
+
+	log.Printf("Crawling from start URLs %v with %d workers", crawl.StartURLs, concurrency)
+
+	report := &scrapeReport{Crawl: &crawl}
+	pagesPerSet := make(map[string]int)
+	opts := src.CrawlOptions{Concurrency: concurrency, Limiter: limiter, Robots: robots, Filter: filter, Limit: limit}
+	unreachable, err := src.Crawl(crawl, config, opts, func(result src.ScrapeResult) {
+		report.URLs = append(report.URLs, result.URL)
+		if result.Err != nil {
+			report.Failed = append(report.Failed, result.URL.Loc)
+			return
+		}
+		log.Printf("Scraped %s: %d documents (selector set %q)", result.URL.Loc, len(result.Documents), result.SelectorSet)
+		pagesPerSet[result.SelectorSet]++
+		report.Documents += len(result.Documents)
+		handle(result.Documents)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to crawl: %w", err)
+	}
+	report.Unreachable = unreachable
+
+	log.Printf("Crawled %d pages, %d failed", len(report.URLs), len(report.Failed))
+	logSelectorSets(config, pagesPerSet)
+	return report, nil
+}
+
+// filterURLs applies the include/exclude rules from the config file and the
+// --include/--exclude flags, logging how many URLs each rule removed.
+func filterURLs(cmd *cobra.Command, config *src.Config, urls []src.URL) []src.URL {
+	filter := urlFilter(cmd, config)
+	if filter.Empty() {
+		return urls
+	}
+
+	filtered, report := filter.Apply(urls)
+	if filter.HasInclude() {
+		log.Printf("Include rules removed %d URLs", report.NotIncluded)
+	}
+	for _, rule := range report.Excluded {
+		log.Printf("Exclude rule %q removed %d URLs", rule.Pattern, rule.Removed)
+	}
+	log.Printf("%d of %d URLs left after URL rules", len(filtered), len(urls))
+
+	return filtered
+}
+
+// urlFilter builds the filter from the include/exclude rules of the config
+// file and the --include/--exclude flags.
+func urlFilter(cmd *cobra.Command, config *src.Config) *src.URLFilter {
+	include, _ := cmd.Flags().GetStringArray("include")
+	exclude, _ := cmd.Flags().GetStringArray("exclude")
+	include = append(append([]string{}, config.URLs.Include...), include...)
+	exclude = append(append([]string{}, config.URLs.Exclude...), exclude...)
+
+	filter, err := src.NewURLFilter(include, exclude)
+	if err != nil {
+		log.Fatalf("Invalid URL rules: %v", err)
+	}
+	return filter
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -23,10 +23,8 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		meilisearchURL := viper.GetString("meilisearch.url")
 		meilisearchKey := viper.GetString("meilisearch.key")
-		indexName := viper.GetString("meilisearch.index")
-		if indexName == "" {
-			indexName = "docs"
-		}
+		config := loadOptionalConfig()
+		indexName := indexName(&config)
 
 		if meilisearchURL == "" {
 			log.Fatal("MEILISEARCH_HOST_URL is required")
@@ -44,6 +42,13 @@ Examples:
 			log.Fatalf("Failed to delete documents: %v", err)
 		}
 		log.Printf("Delete task ID: %d", task.TaskUID)
+		if err := waitForTask(cmd, client, task, "delete"); err != nil {
+			log.Fatal(err)
+		}
 		log.Println("All documents deleted successfully")
 	},
 }
+
+func init() {
+	addTaskFlags(deleteCmd)
+}
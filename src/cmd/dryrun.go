@@ -4,11 +4,9 @@ import (
 	"encoding/json"
 	"log"
 	"os"
-	"time"
 
 	"github.com/jansaidl/meilisearch-scraper/src"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var dryRunCmd = &cobra.Command{
@@ -22,62 +20,24 @@ Examples:
   meilisearch-scraper dry-run https://docs.example.com/sitemap.xml
 
   # Dry run with limit
-  meilisearch-scraper dry-run https://docs.example.com/sitemap.xml --limit 5`,
+  meilisearch-scraper dry-run https://docs.example.com/sitemap.xml --limit 5
+
+  # Dry run in crawl mode
+  meilisearch-scraper dry-run --crawl https://wiki.example.com/docs/ --max-pages 50`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		sitemapURL := viper.GetString("sitemap.url")
-		if len(args) > 0 {
-			sitemapURL = args[0]
-		}
-		if sitemapURL == "" {
-			log.Fatal("Sitemap URL is required (use argument or SITEMAP_URL env variable)")
-		}
-
-		limit, _ := cmd.Flags().GetInt("limit")
-
-		configPath := viper.GetString("config")
-		if configPath == "" {
-			configPath = "config.json"
-		}
-
-		configFile, err := os.ReadFile(configPath)
-		if err != nil {
-			log.Fatalf("Failed to read config file %s: %v", configPath, err)
-		}
-
-		var config src.Config
-		if err := json.Unmarshal(configFile, &config); err != nil {
-			log.Fatalf("Failed to parse config file: %v", err)
-		}
+		config := loadConfig()
 
-		log.Printf("Starting dry-run for sitemap: %s", sitemapURL)
+		log.Println("Starting dry-run")
 		log.Println("Will save to data.json")
 
-		sitemap, err := src.FetchSitemap(sitemapURL)
-		if err != nil {
-			log.Fatalf("Failed to fetch sitemap: %v", err)
-		}
-
-		log.Printf("Found %d URLs in sitemap", len(sitemap.URLs))
-
-		urlsToProcess := sitemap.URLs
-		if limit > 0 && limit < len(sitemap.URLs) {
-			urlsToProcess = sitemap.URLs[:limit]
-			log.Printf("Limiting to %d URLs", limit)
-		}
+		pages := discoverPages(cmd, args, &config)
 
 		var documents []src.Document
-		for i, url := range urlsToProcess {
-			log.Printf("Scraping %d/%d: %s", i+1, len(urlsToProcess), url.Loc)
-
-			docs, err := src.ScrapePage(url.Loc, &config)
-			if err != nil {
-				log.Printf("Failed to scrape %s: %v", url.Loc, err)
-				continue
-			}
-
-			documents = append(documents, docs...)
-			time.Sleep(200 * time.Millisecond)
+		if _, err := pages.scrape(cmd, &config, func(page []src.Document) {
+			documents = append(documents, page...)
+		}); err != nil {
+			log.Fatal(err)
 		}
 
 		log.Printf("Successfully scraped %d documents", len(documents))
@@ -98,5 +58,5 @@ Examples:
 }
 
 func init() {
-	dryRunCmd.Flags().Int("limit", 0, "Limit number of URLs to process (0 = no limit)")
+	addScrapeFlags(dryRunCmd)
 }
@@ -3,10 +3,10 @@ package cmd
 import (
 	"fmt"
 	"log"
-	"net/http"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/jansaidl/meilisearch-scraper/src"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +15,7 @@ var inspectCmd = &cobra.Command{
 	Short: "Inspect HTML elements at a URL using CSS selector",
 	Long: `Inspect HTML elements at a given URL using a CSS selector.
 This helps you understand the page structure and test selectors before scraping.
+The page is fetched through the url_rewrite of the config file, if one exists.
 
 Examples:
   # Inspect h1 elements
@@ -30,26 +31,20 @@ Examples:
 		inspectURL := args[0]
 		selector := args[1]
 
-		fetchURL := inspectURL
-		if !strings.HasSuffix(inspectURL, ".html") {
-			fetchURL = inspectURL + ".html"
-		}
+		config := loadOptionalConfig()
 
-		log.Printf("Inspecting URL: %s (fetching: %s) with selector: %s", inspectURL, fetchURL, selector)
+		src.DefaultClient.AuthorizeHosts([]string{inspectURL})
 
-		resp, err := http.Get(fetchURL)
+		fetchURL, err := src.RewriteURL(inspectURL, config.URLRewrite)
 		if err != nil {
-			log.Fatalf("Failed to fetch page: %v", err)
+			log.Fatalf("Failed to rewrite URL: %v", err)
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			log.Fatalf("Unexpected status code: %d", resp.StatusCode)
-		}
+		log.Printf("Inspecting URL: %s (fetching: %s) with selector: %s", inspectURL, fetchURL, selector)
 
-		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		doc, err := src.FetchDocument(fetchURL)
 		if err != nil {
-			log.Fatalf("Failed to parse HTML: %v", err)
+			log.Fatalf("Failed to fetch page: %v", err)
 		}
 
 		selection := doc.Find(selector)
@@ -45,6 +45,8 @@ func init() {
 	RootCmd.AddCommand(listCmd)
 	RootCmd.AddCommand(detailCmd)
 	RootCmd.AddCommand(searchCmd)
+	RootCmd.AddCommand(settingsCmd)
+	RootCmd.AddCommand(tasksCmd)
 }
 
 func initConfig() {
@@ -1,10 +1,7 @@
 package cmd
 
 import (
-	"encoding/json"
 	"log"
-	"os"
-	"time"
 
 	"github.com/jansaidl/meilisearch-scraper/src"
 	"github.com/meilisearch/meilisearch-go"
@@ -19,6 +16,24 @@ var runCmd = &cobra.Command{
 and upload the documents to Meilisearch for full-text search.
 
 The sitemap URL can be provided as argument or via SITEMAP_URL environment variable.
+Sites without a sitemap can be crawled instead by following links from start URLs
+given with --crawl or crawl.start_urls in the config file. When a site root such as
+https://docs.example.com/ is given, the sitemaps are discovered from its robots.txt.
+
+robots.txt rules and Crawl-delay are honoured unless --ignore-robots is set.
+
+The index settings configured under index_settings (or DocSearch-style defaults)
+are applied before uploading unless --skip-settings is set. Documents are uploaded
+in batches of at most --batch-size documents and --batch-bytes bytes while scraping
+continues, and every batch task is waited for at the end.
+
+With --atomic, documents are uploaded into a temporary index which replaces the
+live index only if it holds at least --min-documents documents (and --min-ratio
+of the live document count), so searches never see a partial index.
+
+With --sync, indexed documents of the scraped site that this run did not produce
+again (deleted pages, renamed sections) are removed after the upload. --dry-run
+reports the added, updated and removed counts without changing the index.
 
 Examples:
   # Run with sitemap URL argument
@@ -29,25 +44,23 @@ Examples:
   meilisearch-scraper run
 
   # Limit number of URLs to process
-  meilisearch-scraper run https://docs.example.com/sitemap.xml --limit 10`,
-	Args: cobra.MaximumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		sitemapURL := viper.GetString("sitemap.url")
-		if len(args) > 0 {
-			sitemapURL = args[0]
-		}
-		if sitemapURL == "" {
-			log.Fatal("Sitemap URL is required (use argument or SITEMAP_URL env variable)")
-		}
+  meilisearch-scraper run https://docs.example.com/sitemap.xml --limit 10
 
-		limit, _ := cmd.Flags().GetInt("limit")
+  # Discover sitemaps from robots.txt
+  meilisearch-scraper run https://docs.example.com/
 
+  # Reindex without downtime, refusing to swap if less than 80% of the documents remain
+  meilisearch-scraper run https://docs.example.com/sitemap.xml --atomic --min-ratio 0.8
+
+  # Preview which stale documents a sync would remove
+  meilisearch-scraper run https://docs.example.com/sitemap.xml --sync --dry-run
+
+  # Crawl a site without a sitemap
+  meilisearch-scraper run --crawl https://wiki.example.com/docs/ --allow-prefix https://wiki.example.com/docs/ --max-depth 3`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
 		meilisearchURL := viper.GetString("meilisearch.url")
 		meilisearchKey := viper.GetString("meilisearch.key")
-		indexName := viper.GetString("meilisearch.index")
-		if indexName == "" {
-			indexName = "docs"
-		}
 
 		if meilisearchURL == "" {
 			log.Fatal("MEILISEARCH_HOST_URL is required")
@@ -56,68 +69,95 @@ Examples:
 			log.Fatal("MEILISEARCH_API_KEY is required")
 		}
 
-		configPath := viper.GetString("config")
-		if configPath == "" {
-			configPath = "config.json"
+		atomic, _ := cmd.Flags().GetBool("atomic")
+		sync, _ := cmd.Flags().GetBool("sync")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if atomic && sync {
+			log.Fatal("--atomic and --sync cannot be combined")
 		}
-
-		configFile, err := os.ReadFile(configPath)
-		if err != nil {
-			log.Fatalf("Failed to read config file %s: %v", configPath, err)
+		if dryRun && !sync {
+			log.Fatal("--dry-run requires --sync; use the dry-run command to scrape without uploading")
 		}
-
-		var config src.Config
-		if err := json.Unmarshal(configFile, &config); err != nil {
-			log.Fatalf("Failed to parse config file: %v", err)
+		if scope, _ := cmd.Flags().GetString("sync-scope"); scope != src.SyncScopeSite && scope != src.SyncScopePages {
+			log.Fatalf("Invalid --sync-scope %q: use %s or %s", scope, src.SyncScopeSite, src.SyncScopePages)
 		}
 
-		log.Printf("Starting scraper for sitemap: %s", sitemapURL)
-
-		sitemap, err := src.FetchSitemap(sitemapURL)
-		if err != nil {
-			log.Fatalf("Failed to fetch sitemap: %v", err)
+		config := loadConfig()
+		indexName := indexName(&config)
+
+		log.Println("Starting scraper")
+
+		pages := discoverPages(cmd, args, &config)
+
+		client := meilisearch.New(meilisearchURL, meilisearch.WithAPIKey(meilisearchKey))
+		skipSettings, _ := cmd.Flags().GetBool("skip-settings")
+
+		// Documents are uploaded in batches while scraping continues, so the
+		// target index and its settings are prepared first.
+		target := indexName
+		var reindex *atomicReindex
+		var indexed []src.IndexedDocument
+		switch {
+		case atomic:
+			reindex = prepareAtomic(cmd, client, indexName, &config)
+			target = reindex.tmpName
+		case sync:
+			indexed = prepareSync(client.Index(indexName))
+			if !dryRun && !skipSettings {
+				applyRunSettings(cmd, client, indexName, &config)
+			}
+		case !skipSettings:
+			applyRunSettings(cmd, client, indexName, &config)
 		}
 
-		log.Printf("Found %d URLs in sitemap", len(sitemap.URLs))
-
-		urlsToProcess := sitemap.URLs
-		if limit > 0 && limit < len(sitemap.URLs) {
-			urlsToProcess = sitemap.URLs[:limit]
-			log.Printf("Limiting to %d URLs", limit)
+		if !dryRun {
+			log.Printf("Uploading documents to Meilisearch index: %s", target)
 		}
-
-		var documents []src.Document
-		for i, url := range urlsToProcess {
-			log.Printf("Scraping %d/%d: %s", i+1, len(urlsToProcess), url.Loc)
-
-			docs, err := src.ScrapePage(url.Loc, &config)
-			if err != nil {
-				log.Printf("Failed to scrape %s: %v", url.Loc, err)
-				continue
+		abort := func(err error) {
+			if reindex != nil {
+				abortAtomic(client, reindex.tmpName, "%v", err)
 			}
-
-			documents = append(documents, docs...)
-			time.Sleep(200 * time.Millisecond)
+			log.Fatal(err)
+		}
+		// A failed upload stops the run while pages are still being scraped.
+		uploads := newUploader(cmd, client, target, &config, dryRun, abort)
+		report, err := pages.scrape(cmd, &config, uploads.Add)
+		if err == nil {
+			log.Printf("Successfully scraped %d documents", report.Documents)
+			err = uploads.Close()
+		}
+		if err != nil {
+			abort(err)
 		}
 
-		log.Printf("Successfully scraped %d documents", len(documents))
-
-		if len(documents) > 0 {
-			client := meilisearch.New(meilisearchURL, meilisearch.WithAPIKey(meilisearchKey))
-			index := client.Index(indexName)
-
-			log.Printf("Uploading documents to Meilisearch index: %s", indexName)
-			task, err := index.AddDocuments(documents, nil)
-			if err != nil {
-				log.Fatalf("Failed to add documents: %v", err)
-			}
-			log.Printf("Upload task ID: %d", task.TaskUID)
+		switch {
+		case atomic:
+			reindex.finish(cmd, client, indexName)
+		case sync:
+			finishSync(cmd, client, indexName, report, uploads.produced, indexed)
 		}
 
 		log.Println("Scraping completed successfully")
 	},
 }
 
+// applyRunSettings applies the index settings before uploading.
+func applyRunSettings(cmd *cobra.Command, client meilisearch.ServiceManager, indexName string, config *src.Config) {
+	if err := applySettings(cmd, client, indexName, config); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func init() {
-	runCmd.Flags().Int("limit", 0, "Limit number of URLs to process (0 = no limit)")
+	addScrapeFlags(runCmd)
+	addTaskFlags(runCmd)
+	addUploadFlags(runCmd)
+	runCmd.Flags().Bool("skip-settings", false, "Do not update the index settings before uploading")
+	runCmd.Flags().Bool("atomic", false, "Upload into a temporary index and swap it with the live index")
+	runCmd.Flags().Int64("min-documents", 1, "With --atomic, minimum number of documents required before swapping")
+	runCmd.Flags().Float64("min-ratio", 0, "With --atomic, minimum document count as a fraction of the live index (e.g. 0.8)")
+	runCmd.Flags().Bool("sync", false, "Remove indexed documents of the scraped site that this run no longer produced")
+	runCmd.Flags().String("sync-scope", src.SyncScopeSite, "With --sync, remove stale documents of the scraped hosts (site) or only of the scraped pages (pages)")
+	runCmd.Flags().Float64("max-remove-ratio", 0.5, "With --sync, refuse to remove more than this fraction of the indexed documents in scope (0 = no check)")
+	runCmd.Flags().Bool("dry-run", false, "With --sync, only report what would be added, updated and removed")
 }
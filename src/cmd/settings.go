@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/jansaidl/meilisearch-scraper/src"
+	"github.com/meilisearch/meilisearch-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Show, diff or apply Meilisearch index settings",
+	Long: `Manage the Meilisearch index settings configured under index_settings in the
+config file, without re-scraping. Settings not given in the config file use
+DocSearch-style defaults.
+
+Examples:
+  # Show the current settings of the index
+  meilisearch-scraper settings show
+
+  # Show the settings that differ from the config file
+  meilisearch-scraper settings diff
+
+  # Apply the configured settings
+  meilisearch-scraper settings apply`,
+}
+
+var settingsShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the current index settings",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadOptionalConfig()
+		client, indexName := settingsClient(&config)
+		index := client.Index(indexName)
+
+		settings, err := index.GetSettings()
+		if err != nil {
+			log.Fatalf("Failed to get settings of index %s: %v", indexName, err)
+		}
+
+		data, err := json.MarshalIndent(settings, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal settings: %v", err)
+		}
+		fmt.Println(string(data))
+	},
+}
+
+var settingsDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the configured settings that differ from the index",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadOptionalConfig()
+		client, indexName := settingsClient(&config)
+		index := client.Index(indexName)
+
+		settings, err := index.GetSettings()
+		if err != nil {
+			log.Fatalf("Failed to get settings of index %s: %v", indexName, err)
+		}
+
+		changes := src.DiffSettings(settings, config.IndexSettings.WithDefaults())
+		if len(changes) == 0 {
+			fmt.Printf("Settings of index %s match the config\n", indexName)
+			return
+		}
+
+		fmt.Printf("Index: %s\n", indexName)
+		for _, change := range changes {
+			fmt.Printf("\n%s:\n", change.Name)
+			fmt.Printf("  - %s\n", change.Current)
+			fmt.Printf("  + %s\n", change.Desired)
+		}
+	},
+}
+
+var settingsApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply the configured settings to the index",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadOptionalConfig()
+		client, indexName := settingsClient(&config)
+
+		if err := applySettings(cmd, client, indexName, &config); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// settingsClient connects to Meilisearch and returns the index name given by
+// the config, flags or environment.
+func settingsClient(config *src.Config) (meilisearch.ServiceManager, string) {
+	meilisearchURL := viper.GetString("meilisearch.url")
+	meilisearchKey := viper.GetString("meilisearch.key")
+
+	if meilisearchURL == "" {
+		log.Fatal("MEILISEARCH_HOST_URL is required")
+	}
+	if meilisearchKey == "" {
+		log.Fatal("MEILISEARCH_API_KEY is required")
+	}
+
+	indexName := indexName(config)
+
+	client := meilisearch.New(meilisearchURL, meilisearch.WithAPIKey(meilisearchKey))
+	return client, indexName
+}
+
+// applySettings updates the index with the configured settings, falling back
+// to the defaults for settings the config leaves unset, and waits for the
+// settings task.
+func applySettings(cmd *cobra.Command, client meilisearch.ServiceManager, indexName string, config *src.Config) error {
+	log.Println("Updating index settings")
+	task, err := client.Index(indexName).UpdateSettings(config.IndexSettings.WithDefaults().Meilisearch())
+	if err != nil {
+		return fmt.Errorf("failed to update settings: %w", err)
+	}
+	log.Printf("Settings task ID: %d", task.TaskUID)
+	return waitForTask(cmd, client, task, "settings")
+}
+
+func init() {
+	settingsCmd.AddCommand(settingsShowCmd)
+	settingsCmd.AddCommand(settingsDiffCmd)
+	settingsCmd.AddCommand(settingsApplyCmd)
+	addTaskFlags(settingsApplyCmd)
+}
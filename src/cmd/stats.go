@@ -24,10 +24,8 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		meilisearchURL := viper.GetString("meilisearch.url")
 		meilisearchKey := viper.GetString("meilisearch.key")
-		indexName := viper.GetString("meilisearch.index")
-		if indexName == "" {
-			indexName = "docs"
-		}
+		config := loadOptionalConfig()
+		indexName := indexName(&config)
 
 		if meilisearchURL == "" {
 			log.Fatal("MEILISEARCH_HOST_URL is required")
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jansaidl/meilisearch-scraper/src"
+	"github.com/meilisearch/meilisearch-go"
+	"github.com/spf13/cobra"
+)
+
+// indexedDocumentsPage is how many document IDs are fetched per request.
+const indexedDocumentsPage = 1000
+
+// prepareSync returns the documents in the index before the run, so that the
+// documents it no longer produces can be found once the upload is done.
+func prepareSync(index meilisearch.IndexManager) []src.IndexedDocument {
+	indexed, err := fetchIndexedDocuments(index)
+	if err != nil {
+		log.Fatalf("Failed to fetch indexed documents: %v", err)
+	}
+	log.Printf("Found %d indexed documents", len(indexed))
+	return indexed
+}
+
+// finishSync deletes the indexed documents in scope that the run no longer
+// produced. With --dry-run it only reports what would change.
+func finishSync(cmd *cobra.Command, client meilisearch.ServiceManager, indexName string, report *scrapeReport, produced, indexed []src.IndexedDocument) {
+	mode, _ := cmd.Flags().GetString("sync-scope")
+	if mode == src.SyncScopeSite {
+		// A partial run must not remove the documents of the pages it skipped.
+		if reason := partialRunReason(cmd, report); reason != "" {
+			log.Printf("Limiting sync to the scraped pages because %s", reason)
+			mode = src.SyncScopePages
+		}
+	}
+
+	scraped := make([]string, 0, len(report.URLs)+len(produced))
+	for _, u := range report.URLs {
+		scraped = append(scraped, u.Loc)
+	}
+	for _, doc := range produced {
+		scraped = append(scraped, doc.URL)
+	}
+	scope, err := src.NewSyncScope(mode, scraped, report.Failed)
+	if err != nil {
+		log.Fatalf("Invalid --sync-scope: %v", err)
+	}
+	if len(report.Failed) > 0 {
+		log.Printf("Keeping the documents of %d pages that failed to scrape", len(report.Failed))
+	}
+
+	plan := src.PlanSync(produced, indexed, scope)
+	log.Printf("Sync (scope %s): %d added, %d updated, %d removed", mode, plan.Added, plan.Updated, len(plan.Stale))
+
+	// Removing a large part of the index usually means the run missed pages
+	// rather than that the site shrank.
+	var tooMany string
+	if maxRatio, _ := cmd.Flags().GetFloat64("max-remove-ratio"); maxRatio > 0 && plan.StaleRatio() > maxRatio {
+		tooMany = fmt.Sprintf("%d of %d indexed documents in scope would be removed, more than --max-remove-ratio %g", len(plan.Stale), plan.InScope, maxRatio)
+	}
+
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		for _, doc := range plan.Stale {
+			fmt.Printf("remove %s %s\n", doc.ObjectID, doc.URL)
+		}
+		if tooMany != "" {
+			log.Printf("WARNING: %s; the sync would be refused", tooMany)
+		}
+		log.Println("Dry run: the index was not changed")
+		return
+	}
+	if tooMany != "" {
+		log.Fatalf("Refusing to remove stale documents: %s", tooMany)
+	}
+
+	if len(plan.Stale) > 0 {
+		ids := make([]string, 0, len(plan.Stale))
+		for _, doc := range plan.Stale {
+			ids = append(ids, doc.ObjectID)
+		}
+		log.Printf("Deleting %d stale documents", len(ids))
+		task, err := client.Index(indexName).DeleteDocuments(ids, nil)
+		if err != nil {
+			log.Fatalf("Failed to delete stale documents: %v", err)
+		}
+		log.Printf("Delete task ID: %d", task.TaskUID)
+		if err := waitForTask(cmd, client, task, "delete"); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// partialRunReason explains why a run may have missed pages of the site, or
+// returns "" if it covered the whole site.
+func partialRunReason(cmd *cobra.Command, report *scrapeReport) string {
+	for _, flag := range []string{"limit", "since", "include", "exclude", "max-pages", "max-depth"} {
+		if cmd.Flags().Changed(flag) {
+			return fmt.Sprintf("--%s is set", flag)
+		}
+	}
+	if report.Crawl != nil && report.Crawl.MaxPages > 0 {
+		return "crawl.max_pages is set"
+	}
+	if report.Crawl != nil && report.Crawl.MaxDepth > 0 {
+		return "crawl.max_depth is set"
+	}
+	if len(report.Unreachable) > 0 {
+		if report.Crawl != nil {
+			return fmt.Sprintf("%d crawled pages could not be fetched", len(report.Unreachable))
+		}
+		return fmt.Sprintf("%d sitemaps could not be fetched", len(report.Unreachable))
+	}
+	return ""
+}
+
+// fetchIndexedDocuments returns the objectID and URL of every document in the
+// index.
+func fetchIndexedDocuments(index meilisearch.IndexManager) ([]src.IndexedDocument, error) {
+	var indexed []src.IndexedDocument
+	for offset := int64(0); ; offset += indexedDocumentsPage {
+		var resp meilisearch.DocumentsResult
+		err := index.GetDocuments(&meilisearch.DocumentsQuery{
+			Offset: offset,
+			Limit:  indexedDocumentsPage,
+			Fields: []string{"objectID", "url"},
+		}, &resp)
+		if isNotFound(err) {
+			// Nothing to compare against before the first upload.
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var page []src.IndexedDocument
+		if err := resp.Results.DecodeInto(&page); err != nil {
+			return nil, err
+		}
+		indexed = append(indexed, page...)
+
+		if len(page) < indexedDocumentsPage || offset+int64(len(page)) >= resp.Total {
+			return indexed, nil
+		}
+	}
+}
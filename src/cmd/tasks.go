@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/meilisearch/meilisearch-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// defaultTaskTimeout is how long commands wait for a Meilisearch task to
+// finish unless --task-timeout is given.
+const defaultTaskTimeout = 5 * time.Minute
+
+// taskPollInterval is how often a waiting command polls the task status.
+const taskPollInterval = 500 * time.Millisecond
+
+var tasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "List recent Meilisearch tasks of the index",
+	Long: `List the most recent Meilisearch tasks of the index with their status, or show
+the details of a single task with "tasks show".
+
+Examples:
+  # List recent tasks of the default index
+  meilisearch-scraper tasks
+
+  # List failed tasks only
+  meilisearch-scraper tasks --status failed --limit 50
+
+  # Show details of a task
+  meilisearch-scraper tasks show 42`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := tasksClient()
+		config := loadOptionalConfig()
+		indexName := indexName(&config)
+
+		limit, _ := cmd.Flags().GetInt64("limit")
+		query := &meilisearch.TasksQuery{IndexUIDS: []string{indexName}, Limit: limit}
+		statuses, _ := cmd.Flags().GetStringSlice("status")
+		for _, status := range statuses {
+			query.Statuses = append(query.Statuses, meilisearch.TaskStatus(status))
+		}
+
+		result, err := client.GetTasks(query)
+		if err != nil {
+			log.Fatalf("Failed to get tasks: %v", err)
+		}
+
+		fmt.Printf("Index: %s\n", indexName)
+		fmt.Printf("Showing %d of %d tasks\n\n", len(result.Results), result.Total)
+		for _, task := range result.Results {
+			fmt.Printf("%-8d %-28s %-10s %s", task.UID, task.Type, task.Status, task.EnqueuedAt.Format(time.RFC3339))
+			if task.Duration != "" {
+				fmt.Printf(" (%s)", task.Duration)
+			}
+			if task.Error.Code != "" {
+				fmt.Printf(" %s: %s", task.Error.Code, task.Error.Message)
+			}
+			fmt.Println()
+		}
+	},
+}
+
+var tasksShowCmd = &cobra.Command{
+	Use:   "show <task-uid>",
+	Short: "Show details of a Meilisearch task",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		uid, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid task UID %q", args[0])
+		}
+
+		task, err := tasksClient().GetTask(uid)
+		if err != nil {
+			log.Fatalf("Failed to get task %d: %v", uid, err)
+		}
+
+		data, err := json.MarshalIndent(task, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal task: %v", err)
+		}
+		fmt.Println(string(data))
+	},
+}
+
+func tasksClient() meilisearch.ServiceManager {
+	meilisearchURL := viper.GetString("meilisearch.url")
+	meilisearchKey := viper.GetString("meilisearch.key")
+
+	if meilisearchURL == "" {
+		log.Fatal("MEILISEARCH_HOST_URL is required")
+	}
+	if meilisearchKey == "" {
+		log.Fatal("MEILISEARCH_API_KEY is required")
+	}
+
+	return meilisearch.New(meilisearchURL, meilisearch.WithAPIKey(meilisearchKey))
+}
+
+// addTaskFlags registers the flags controlling how long a command waits for
+// its Meilisearch tasks.
+func addTaskFlags(cmd *cobra.Command) {
+	cmd.Flags().Duration("task-timeout", defaultTaskTimeout, "How long to wait for each Meilisearch task to finish")
+}
+
+// waitForTask polls a task until it has finished or --task-timeout has
+// passed, logs its final status and returns an error unless it succeeded.
+func waitForTask(cmd *cobra.Command, client meilisearch.ServiceManager, task *meilisearch.TaskInfo, what string) error {
+	timeout, _ := cmd.Flags().GetDuration("task-timeout")
+	if timeout <= 0 {
+		timeout = defaultTaskTimeout
+	}
+
+	log.Printf("Waiting for %s task %d", what, task.TaskUID)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	result, err := client.WaitForTaskWithContext(ctx, task.TaskUID, taskPollInterval)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s task %d did not finish within %s; check it with: meilisearch-scraper tasks show %d", what, task.TaskUID, timeout, task.TaskUID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to wait for %s task %d: %w", what, task.TaskUID, err)
+	}
+
+	if result.Status != meilisearch.TaskStatusSucceeded {
+		return fmt.Errorf("%s task %d %s: %s (%s)", what, task.TaskUID, result.Status, result.Error.Message, result.Error.Code)
+	}
+	if result.Duration != "" {
+		log.Printf("Task %d (%s) succeeded in %s", task.TaskUID, what, result.Duration)
+	} else {
+		log.Printf("Task %d (%s) succeeded", task.TaskUID, what)
+	}
+	return nil
+}
+
+func init() {
+	tasksCmd.Flags().Int64("limit", 20, "Number of tasks to list")
+	tasksCmd.Flags().StringSlice("status", nil, "Only list tasks with these statuses (enqueued, processing, succeeded, failed, canceled)")
+	tasksCmd.AddCommand(tasksShowCmd)
+}
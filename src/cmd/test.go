@@ -4,11 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 
 	"github.com/jansaidl/meilisearch-scraper/src"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var testCmd = &cobra.Command{
@@ -27,26 +25,32 @@ Examples:
 	Run: func(cmd *cobra.Command, args []string) {
 		testURL := args[0]
 
-		configPath := viper.GetString("config")
-		if configPath == "" {
-			configPath = "config.json"
-		}
+		config := loadConfig()
 
-		configFile, err := os.ReadFile(configPath)
-		if err != nil {
-			log.Fatalf("Failed to read config file %s: %v", configPath, err)
-		}
+		src.DefaultClient.AuthorizeHosts([]string{testURL})
 
-		var config src.Config
-		if err := json.Unmarshal(configFile, &config); err != nil {
-			log.Fatalf("Failed to parse config file: %v", err)
+		fetchURL, err := src.RewriteURL(testURL, config.URLRewrite)
+		if err != nil {
+			log.Fatalf("Failed to rewrite URL: %v", err)
 		}
 
-		log.Printf("Testing scraping for URL: %s", testURL)
-		docs, err := src.ScrapePage(testURL, &config)
+		log.Printf("Testing scraping for URL: %s (fetching: %s)", testURL, fetchURL)
+		result, err := src.ScrapePage(src.URL{Loc: testURL}, &config)
 		if err != nil {
 			log.Fatalf("Failed to scrape page: %v", err)
 		}
+		docs := result.Documents
+
+		log.Printf("Using selector set: %s", result.SelectorSet)
+		if result.Metadata.Title != "" {
+			log.Printf("Page title: %s", result.Metadata.Title)
+		}
+		if result.Metadata.Canonical != "" {
+			log.Printf("Canonical URL: %s", result.Metadata.Canonical)
+		}
+		for _, removed := range result.Removed {
+			log.Printf("Removed %d elements matching %q", removed.Removed, removed.Pattern)
+		}
 
 		data, err := json.MarshalIndent(docs, "", "  ")
 		if err != nil {
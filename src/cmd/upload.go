@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/jansaidl/meilisearch-scraper/src"
+	"github.com/meilisearch/meilisearch-go"
+	"github.com/spf13/cobra"
+)
+
+// uploadQueueSize is how many scraped pages can wait for the uploader before
+// scraping blocks.
+const uploadQueueSize = 64
+
+// uploader sends documents to an index in batches while pages are still
+// being scraped. It runs on its own goroutine, so scraping continues while a
+// batch is sent, and it records each batch's task to wait for at the end.
+// The first failed request or task is passed to fail right away, so that the
+// command can stop scraping instead of failing only once the site is done.
+type uploader struct {
+	cmd       *cobra.Command
+	client    meilisearch.ServiceManager
+	indexName string
+	dryRun    bool
+	fail      func(error)
+
+	pages chan []src.Document
+	done  chan struct{}
+
+	// Owned by the uploader goroutine until done is closed.
+	batcher   *src.Batcher
+	batches   []uploadBatch
+	produced  []src.IndexedDocument
+	documents int
+	err       error
+
+	// checked is the number of leading batches whose task succeeded.
+	checked int
+}
+
+type uploadBatch struct {
+	number    int
+	documents int
+	bytes     int
+	task      *meilisearch.TaskInfo
+}
+
+// newUploader starts an uploader for the index. With dryRun nothing is sent,
+// but the produced documents are still recorded. fail is called on the
+// uploader goroutine with the first error.
+func newUploader(cmd *cobra.Command, client meilisearch.ServiceManager, indexName string, config *src.Config, dryRun bool, fail func(error)) *uploader {
+	batchSize := config.Upload.BatchSize
+	if cmd.Flags().Changed("batch-size") {
+		batchSize, _ = cmd.Flags().GetInt("batch-size")
+	}
+	batchBytes := config.Upload.BatchBytes
+	if cmd.Flags().Changed("batch-bytes") {
+		batchBytes, _ = cmd.Flags().GetInt("batch-bytes")
+	}
+
+	u := &uploader{
+		cmd:       cmd,
+		client:    client,
+		indexName: indexName,
+		dryRun:    dryRun,
+		fail:      fail,
+		pages:     make(chan []src.Document, uploadQueueSize),
+		done:      make(chan struct{}),
+	}
+	u.batcher = src.NewBatcher(batchSize, batchBytes, u.send)
+
+	go u.run()
+	return u
+}
+
+// Add queues the documents of a scraped page for upload.
+func (u *uploader) Add(documents []src.Document) {
+	u.pages <- documents
+}
+
+func (u *uploader) run() {
+	defer close(u.done)
+	for documents := range u.pages {
+		for _, doc := range documents {
+			u.produced = append(u.produced, src.IndexedDocument{ObjectID: doc.ObjectID, URL: doc.URL})
+			u.documents++
+			if err := u.batcher.Add(doc); err != nil {
+				u.setErr(err)
+			}
+		}
+	}
+	u.batcher.Flush()
+}
+
+// send uploads one batch. After a failed request the remaining batches are
+// dropped, since the run fails anyway.
+func (u *uploader) send(batch []json.RawMessage) {
+	// The JSON array adds brackets and separating commas.
+	size := len(batch) + 1
+	for _, doc := range batch {
+		size += len(doc)
+	}
+	number := len(u.batches) + 1
+
+	if u.dryRun || u.err != nil {
+		u.batches = append(u.batches, uploadBatch{number: number, documents: len(batch), bytes: size})
+		return
+	}
+
+	task, err := u.client.Index(u.indexName).AddDocuments(batch, nil)
+	if err != nil {
+		u.setErr(fmt.Errorf("failed to add batch %d: %w", number, err))
+		return
+	}
+	log.Printf("Batch %d: %d documents (%d bytes), task ID: %d", number, len(batch), size, task.TaskUID)
+	u.batches = append(u.batches, uploadBatch{number: number, documents: len(batch), bytes: size, task: task})
+	u.checkTasks()
+}
+
+// checkTasks looks up the tasks of earlier batches that are not known to have
+// succeeded yet, in order, up to the first one still waiting. Tasks of an
+// index run one after another, so this costs about one request per batch.
+func (u *uploader) checkTasks() {
+	for u.checked < len(u.batches) {
+		batch := u.batches[u.checked]
+		task, err := u.client.GetTask(batch.task.TaskUID)
+		if err != nil {
+			// Close waits for the task and reports the problem.
+			return
+		}
+		switch task.Status {
+		case meilisearch.TaskStatusSucceeded:
+			u.checked++
+		case meilisearch.TaskStatusFailed, meilisearch.TaskStatusCanceled:
+			u.setErr(fmt.Errorf("batch %d upload task %d %s: %s (%s)", batch.number, batch.task.TaskUID, task.Status, task.Error.Message, task.Error.Code))
+			return
+		default:
+			return
+		}
+	}
+}
+
+// setErr records the first error and hands it to fail.
+func (u *uploader) setErr(err error) {
+	if u.err != nil {
+		return
+	}
+	u.err = err
+	if u.fail != nil {
+		u.fail(err)
+	}
+}
+
+// Close sends the last batch and waits for the tasks of all batches. It
+// returns an error if a batch could not be sent or its task failed.
+func (u *uploader) Close() error {
+	close(u.pages)
+	<-u.done
+
+	if u.err != nil {
+		return u.err
+	}
+	if u.dryRun {
+		return nil
+	}
+
+	failed := 0
+	for _, batch := range u.batches[u.checked:] {
+		if err := waitForTask(u.cmd, u.client, batch.task, fmt.Sprintf("batch %d upload", batch.number)); err != nil {
+			log.Print(err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d upload batches failed", failed, len(u.batches))
+	}
+
+	log.Printf("Uploaded %d documents in %d batches to index %s", u.documents, len(u.batches), u.indexName)
+	return nil
+}
+
+// addUploadFlags registers the flags controlling upload batches.
+func addUploadFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("batch-size", 0, fmt.Sprintf("Maximum number of documents per upload batch (default %d)", src.DefaultBatchSize))
+	cmd.Flags().Int("batch-bytes", 0, fmt.Sprintf("Maximum JSON size of an upload batch in bytes (default %d)", src.DefaultBatchBytes))
+}
@@ -0,0 +1,81 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// DefaultCodeSelector matches the code blocks extracted into Document.Code.
+const DefaultCodeSelector = "pre code"
+
+// languagePrefixes are the class prefixes syntax highlighters use to name the
+// language of a code block.
+var languagePrefixes = []string{"language-", "lang-"}
+
+// Validate checks the code block selector.
+func (c CodeConfig) Validate() error {
+	if c.Selector == "" {
+		return nil
+	}
+	if _, err := cascadia.Compile(c.Selector); err != nil {
+		return fmt.Errorf("code: invalid selector %q: %w", c.Selector, err)
+	}
+	return nil
+}
+
+func (c CodeConfig) selector() string {
+	if c.Selector == "" {
+		return DefaultCodeSelector
+	}
+	return c.Selector
+}
+
+// newCodeBlock returns the code block of a matched element, keeping its
+// whitespace apart from leading and trailing blank lines.
+func newCodeBlock(s *goquery.Selection) (CodeBlock, bool) {
+	content := strings.Trim(s.Text(), "\r\n")
+	if strings.TrimSpace(content) == "" {
+		return CodeBlock{}, false
+	}
+	return CodeBlock{Language: codeLanguage(s), Content: content}, true
+}
+
+// codeLanguage reads the language from a language-* or lang-* class on the
+// code element or its enclosing pre.
+func codeLanguage(s *goquery.Selection) string {
+	for _, candidate := range []*goquery.Selection{s, s.Closest("pre")} {
+		class, _ := candidate.Attr("class")
+		for _, name := range strings.Fields(class) {
+			for _, prefix := range languagePrefixes {
+				if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+					return strings.TrimPrefix(name, prefix)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// textWithout returns the text of node like goquery's Text, leaving out the
+// text of the skipped elements.
+func textWithout(node *html.Node, skip map[*html.Node]bool) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if skip[n] {
+			return
+		}
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// DefaultSelectorSet names the top-level selectors used when no selector set
+// matches a page.
+const DefaultSelectorSet = "default"
+
+// ParseConfig parses a config file in either the native format or the legacy
+// DocSearch scraper format. For DocSearch configs it returns a warning for
+// every field or option that is not supported and therefore ignored.
+func ParseConfig(data []byte) (*Config, []string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	if !isDocSearchConfig(raw) {
+		var config Config
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, nil, err
+		}
+		return &config, nil, nil
+	}
+
+	return parseDocSearchConfig(raw)
+}
+
+// Validate checks the parts of the config that can be invalid beyond JSON
+// syntax.
+func (c *Config) Validate() error {
+	if err := c.URLRewrite.Validate(); err != nil {
+		return err
+	}
+
+	switch c.RecordGranularity {
+	case "", GranularityText, GranularitySection, GranularityPage:
+	default:
+		return fmt.Errorf("record_granularity: unknown value %q", c.RecordGranularity)
+	}
+
+	if err := c.Chunking.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Code.Validate(); err != nil {
+		return err
+	}
+
+	if err := validateCustomFields(c.CustomFields); err != nil {
+		return err
+	}
+
+	if err := c.Upload.Validate(); err != nil {
+		return err
+	}
+
+	for _, selector := range c.SelectorsExclude {
+		if _, err := cascadia.Compile(selector); err != nil {
+			return fmt.Errorf("selectors_exclude: invalid selector %q: %w", selector, err)
+		}
+	}
+
+	if err := c.Selectors.Tables.Validate(); err != nil {
+		return fmt.Errorf("selectors: %w", err)
+	}
+
+	for i := range c.SelectorSets {
+		set := &c.SelectorSets[i]
+		if set.Name == "" {
+			return fmt.Errorf("selector_sets[%d]: name is required", i)
+		}
+		if set.Match != "" {
+			if _, err := cascadia.Compile(set.Match); err != nil {
+				return fmt.Errorf("selector set %q: invalid match selector %q: %w", set.Name, set.Match, err)
+			}
+		}
+		if err := set.Selectors.Tables.Validate(); err != nil {
+			return fmt.Errorf("selector set %q: %w", set.Name, err)
+		}
+		if err := set.compileURLPatterns(); err != nil {
+			return fmt.Errorf("selector set %q: %w", set.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// selectSelectors returns the first selector set whose URL patterns match the
+// page URL and whose match selector is present on the page, falling back to
+// the top-level selectors. A set without URL patterns matches every URL, and
+// a set without a match selector every page.
+func (c *Config) selectSelectors(pageURL string, goDoc *goquery.Document) (string, *Selectors) {
+	for i := range c.SelectorSets {
+		set := &c.SelectorSets[i]
+		if !set.matchesURL(pageURL) {
+			continue
+		}
+		if set.Match != "" && goDoc.Find(set.Match).Length() == 0 {
+			continue
+		}
+		return set.Name, &set.Selectors
+	}
+	return DefaultSelectorSet, &c.Selectors
+}
+
+// compileURLPatterns compiles the URL patterns once, so that matching a page
+// does not recompile them.
+func (s *SelectorSet) compileURLPatterns() error {
+	rules := make([]urlRule, 0, len(s.URLPatterns))
+	for _, pattern := range s.URLPatterns {
+		rule, err := compileURLRule(pattern)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+	}
+	s.urlRules = rules
+	return nil
+}
+
+func (s *SelectorSet) matchesURL(pageURL string) bool {
+	if len(s.URLPatterns) == 0 {
+		return true
+	}
+	// Configs that skipped Validate get their patterns compiled on first use.
+	if len(s.urlRules) != len(s.URLPatterns) && s.compileURLPatterns() != nil {
+		return false
+	}
+	for _, rule := range s.urlRules {
+		if rule.re.MatchString(pageURL) {
+			return true
+		}
+	}
+	return false
+}
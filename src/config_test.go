@@ -0,0 +1,60 @@
+package src
+
+import "testing"
+
+func TestValidateSelectorSets(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     SelectorSet
+		wantErr bool
+	}{
+		{"valid", SelectorSet{Name: "api", URLPatterns: []string{"*/api/*", "regex:/v[0-9]+/"}, Match: ".api-page"}, false},
+		{"missing name", SelectorSet{Match: ".api-page"}, true},
+		{"invalid match", SelectorSet{Name: "api", Match: "div["}, true},
+		{"invalid pattern", SelectorSet{Name: "api", URLPatterns: []string{"regex:("}}, true},
+	}
+
+	for _, tt := range tests {
+		config := Config{SelectorSets: []SelectorSet{tt.set}}
+		if err := config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSelectSelectors(t *testing.T) {
+	config := Config{
+		Selectors: testSelectors,
+		SelectorSets: []SelectorSet{
+			{Name: "api", URLPatterns: []string{"https://docs.example.com/api/*"}, Match: ".api-page"},
+			{Name: "guides", URLPatterns: []string{"regex:/guides?/"}},
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	apiPage := parseHTML(t, `<html><body><div class="api-page"><h1>API</h1></div></body></html>`)
+	plainPage := parseHTML(t, `<html><body><h1>Page</h1></body></html>`)
+
+	tests := []struct {
+		url  string
+		page string
+		want string
+	}{
+		{"https://docs.example.com/api/search", "api", "api"},
+		{"https://docs.example.com/api/search", "plain", DefaultSelectorSet},
+		{"https://docs.example.com/guide/intro", "plain", "guides"},
+		{"https://docs.example.com/blog/post", "api", DefaultSelectorSet},
+	}
+
+	for _, tt := range tests {
+		goDoc := plainPage
+		if tt.page == "api" {
+			goDoc = apiPage
+		}
+		if got, _ := config.selectSelectors(tt.url, goDoc); got != tt.want {
+			t.Errorf("selectSelectors(%q, %s page) = %q, want %q", tt.url, tt.page, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package src
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// skippedExtensions lists link targets that are never HTML pages.
+var skippedExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true, ".ico": true,
+	".pdf": true, ".zip": true, ".gz": true, ".tar": true, ".tgz": true,
+	".css": true, ".js": true, ".json": true, ".xml": true, ".txt": true,
+	".mp3": true, ".mp4": true, ".webm": true, ".woff": true, ".woff2": true, ".ttf": true,
+}
+
+type crawlItem struct {
+	url   string
+	depth int
+
+	// index numbers the scraped pages in the order they were dispatched; it
+	// is -1 for pages that are only fetched for their links.
+	index int
+}
+
+type crawlResult struct {
+	item  crawlItem
+	links []string
+	page  *PageResult
+	err   error
+}
+
+// CrawlOptions controls how Crawl fetches and scrapes pages.
+type CrawlOptions struct {
+	Concurrency int
+	Limiter     *HostLimiter
+	Robots      *RobotsCache
+
+	// Filter selects the pages that are scraped. The links of the other
+	// pages are still followed. A nil Filter scrapes every page.
+	Filter *URLFilter
+
+	// Limit stops the crawl once this many pages were scraped (0 = no
+	// limit).
+	Limit int
+}
+
+// Crawl discovers pages by following <a href> links breadth-first from the
+// configured start URLs and scrapes them from the same download, calling
+// handle for every scraped page in the order the pages were discovered. Only
+// links under one of the allowed prefixes are followed; when no prefixes are
+// configured, each start URL's host is used. MaxDepth and MaxPages of zero
+// mean no limit. Links disallowed by robots are not followed. Pages are
+// fetched through the configured URL rewrite, by a pool of workers paced by
+// the limiter.
+//
+// Crawl returns the URLs of the pages that could not be fetched, whose links
+// are therefore missing from the crawl.
+func Crawl(crawl CrawlConfig, config *Config, opts CrawlOptions, handle func(ScrapeResult)) ([]string, error) {
+	if len(crawl.StartURLs) == 0 {
+		return nil, fmt.Errorf("no crawl start URLs configured")
+	}
+
+	prefixes := crawl.AllowedPrefixes
+	if len(prefixes) == 0 {
+		for _, start := range crawl.StartURLs {
+			u, err := url.Parse(start)
+			if err != nil {
+				return nil, fmt.Errorf("invalid start URL %s: %w", start, err)
+			}
+			prefixes = append(prefixes, u.Host+"/")
+		}
+	}
+
+	visited := make(map[string]bool)
+	var queue []crawlItem
+	for _, start := range crawl.StartURLs {
+		normalized, ok := normalizeLink(nil, start)
+		if !ok {
+			return nil, fmt.Errorf("invalid start URL: %s", start)
+		}
+		if !visited[normalized] {
+			visited[normalized] = true
+			queue = append(queue, crawlItem{url: normalized})
+		}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan crawlItem)
+	results := make(chan crawlResult)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				opts.Limiter.Wait(item.url)
+				results <- crawlPage(item, config, crawl.MaxDepth)
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	// Pages are fetched and scraped concurrently, but only this loop touches
+	// the queue and the counters.
+	var failed []string
+	fetched, inFlight, scraped := 0, 0, 0
+	pending := make(map[int]ScrapeResult)
+	next := 0
+	for {
+		for inFlight < concurrency && len(queue) > 0 {
+			if crawl.MaxPages > 0 && fetched+inFlight >= crawl.MaxPages {
+				break
+			}
+			if opts.Limit > 0 && scraped >= opts.Limit {
+				break
+			}
+
+			item := queue[0]
+			queue = queue[1:]
+
+			if !opts.Robots.Allowed(item.url) {
+				log.Printf("Skipping %s: disallowed by robots.txt", item.url)
+				continue
+			}
+
+			item.index = -1
+			if opts.Filter == nil || opts.Filter.Match(item.url) {
+				item.index = scraped
+				scraped++
+			}
+
+			log.Printf("Crawling (depth %d): %s", item.depth, item.url)
+			jobs <- item
+			inFlight++
+		}
+		if inFlight == 0 {
+			break
+		}
+
+		result := <-results
+		inFlight--
+
+		if result.err != nil {
+			log.Printf("Failed to crawl %s: %v", result.item.url, result.err)
+			failed = append(failed, result.item.url)
+		} else {
+			fetched++
+		}
+
+		for _, link := range result.links {
+			if visited[link] || !hasAllowedPrefix(link, prefixes) {
+				continue
+			}
+			visited[link] = true
+			queue = append(queue, crawlItem{url: link, depth: result.item.depth + 1})
+		}
+
+		if result.item.index < 0 {
+			continue
+		}
+		pending[result.item.index] = ScrapeResult{
+			Index:      result.item.index,
+			URL:        URL{Loc: result.item.url},
+			PageResult: result.page,
+			Err:        result.err,
+		}
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			handle(r)
+			next++
+		}
+	}
+
+	return failed, nil
+}
+
+// crawlPage fetches a page, collects its links unless it is at the maximum
+// depth, and scrapes it if it was selected for scraping.
+func crawlPage(item crawlItem, config *Config, maxDepth int) crawlResult {
+	result := crawlResult{item: item}
+
+	fetchURL, err := RewriteURL(item.url, config.URLRewrite)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	goDoc, err := FetchDocument(fetchURL)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	// Links are collected first, as scraping removes the excluded elements
+	// that often hold the navigation.
+	if maxDepth <= 0 || item.depth < maxDepth {
+		result.links = pageLinks(item.url, goDoc)
+	}
+	if item.index >= 0 {
+		result.page = scrapeDocument(URL{Loc: item.url}, goDoc, config)
+	}
+	return result
+}
+
+// pageLinks returns the normalized links of a page, resolved against its
+// <base href> if it has one, or else against the URL the page was finally
+// served from after redirects.
+func pageLinks(pageURL string, goDoc *goquery.Document) []string {
+	base := goDoc.Url
+	if base == nil {
+		base, _ = url.Parse(pageURL)
+	}
+	if href, ok := goDoc.Find("base[href]").First().Attr("href"); ok {
+		if resolved, err := base.Parse(href); err == nil {
+			base = resolved
+		}
+	}
+
+	var links []string
+	goDoc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		if link, ok := normalizeLink(base, href); ok {
+			links = append(links, link)
+		}
+	})
+	return links
+}
+
+// normalizeLink resolves href against base and strips the fragment. It
+// reports false for links that cannot point to a crawlable HTML page.
+func normalizeLink(base *url.URL, href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return "", false
+	}
+
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+	if skippedExtensions[strings.ToLower(path.Ext(u.Path))] {
+		return "", false
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	return u.String(), true
+}
+
+// hasAllowedPrefix matches a link against prefixes given either as full URLs
+// or as host/path without a scheme.
+func hasAllowedPrefix(link string, prefixes []string) bool {
+	target := stripScheme(link)
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(target, stripScheme(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func stripScheme(rawURL string) string {
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		return rawURL[i+3:]
+	}
+	return rawURL
+}
@@ -0,0 +1,44 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPageLinksAfterRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/docs" {
+			http.Redirect(w, r, "/docs/", http.StatusMovedPermanently)
+			return
+		}
+		w.Write([]byte(`<html><body><a href="intro">Intro</a><a href="../about">About</a></body></html>`))
+	}))
+	defer server.Close()
+
+	goDoc, err := FetchDocument(server.URL + "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links := pageLinks(server.URL+"/docs", goDoc)
+	want := []string{server.URL + "/docs/intro", server.URL + "/about"}
+	if len(links) != len(want) {
+		t.Fatalf("got links %v, want %v", links, want)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("link %d: got %q, want %q", i, links[i], want[i])
+		}
+	}
+}
+
+func TestPageLinksBaseHref(t *testing.T) {
+	goDoc := parseHTML(t, `<html><head><base href="https://docs.example.com/v2/"></head>
+<body><a href="guide">Guide</a><a href="#top">Top</a><a href="mailto:a@example.com">Mail</a></body></html>`)
+
+	links := pageLinks(testPageURL, goDoc)
+	if len(links) != 1 || links[0] != "https://docs.example.com/v2/guide" {
+		t.Errorf("got links %v, want [https://docs.example.com/v2/guide]", links)
+	}
+}
@@ -0,0 +1,209 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// Validate checks that a custom field has exactly one valid source.
+func (f CustomField) Validate() error {
+	sources := 0
+	for _, set := range []bool{f.Selector != "", f.Meta != "", f.URLPattern != "", f.Value != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one of selector, meta, url_pattern or value is required")
+	}
+
+	if f.Attribute != "" && f.Selector == "" {
+		return fmt.Errorf("attribute requires a selector")
+	}
+	if f.Selector != "" {
+		if _, err := cascadia.Compile(f.Selector); err != nil {
+			return fmt.Errorf("invalid selector %q: %w", f.Selector, err)
+		}
+	}
+	if f.URLPattern != "" {
+		re, err := regexp.Compile(f.URLPattern)
+		if err != nil {
+			return fmt.Errorf("invalid url_pattern %q: %w", f.URLPattern, err)
+		}
+		if group := f.group(re); group < 0 || group > re.NumSubexp() {
+			return fmt.Errorf("url_pattern %q has no group %d", f.URLPattern, group)
+		}
+	}
+	return nil
+}
+
+// group returns the capture group holding the value: the configured group,
+// else the first group, or the whole match if the pattern has no groups.
+func (f CustomField) group(re *regexp.Regexp) int {
+	if f.Group != nil {
+		return *f.Group
+	}
+	if re.NumSubexp() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// validateCustomFields checks every custom field and rejects names that
+// would overwrite a built-in document field.
+func validateCustomFields(fields map[string]CustomField) error {
+	reserved := documentFieldNames()
+	for _, name := range sortedFieldNames(fields) {
+		if name == "" {
+			return fmt.Errorf("custom_fields: field name must not be empty")
+		}
+		if reserved[name] {
+			return fmt.Errorf("custom_fields: %q is a built-in document field", name)
+		}
+		if err := fields[name].Validate(); err != nil {
+			return fmt.Errorf("custom_fields: %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// documentFieldNames returns the JSON names of the built-in document fields.
+func documentFieldNames() map[string]bool {
+	names := make(map[string]bool)
+	t := reflect.TypeOf(Document{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+func sortedFieldNames(fields map[string]CustomField) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// extractCustomFields evaluates the custom fields for a page. Fields without
+// a value and without a default are left out.
+func extractCustomFields(fields map[string]CustomField, pageURL string, goDoc *goquery.Document) map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	values := make(map[string]any, len(fields))
+	for name, field := range fields {
+		if value := field.extract(pageURL, goDoc); value != nil {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// extract returns the value of a field for a page: a string, a []string for
+// multiple values, the constant value, or the default when nothing matched.
+func (f CustomField) extract(pageURL string, goDoc *goquery.Document) any {
+	if f.Value != nil {
+		return f.wrap(f.Value)
+	}
+
+	var found []string
+	switch {
+	case f.Selector != "":
+		goDoc.Find(f.Selector).Each(func(i int, s *goquery.Selection) {
+			if f.Attribute != "" {
+				found = append(found, strings.TrimSpace(s.AttrOr(f.Attribute, "")))
+			} else {
+				found = append(found, strings.Join(strings.Fields(s.Text()), " "))
+			}
+		})
+	case f.Meta != "":
+		selector := fmt.Sprintf(`meta[name=%q], meta[property=%q]`, f.Meta, f.Meta)
+		goDoc.Find(selector).Each(func(i int, s *goquery.Selection) {
+			found = append(found, strings.TrimSpace(s.AttrOr("content", "")))
+		})
+	case f.URLPattern != "":
+		// Validate made sure the pattern compiles and has the group.
+		re := regexp.MustCompile(f.URLPattern)
+		group := f.group(re)
+		for _, match := range re.FindAllStringSubmatch(pageURL, -1) {
+			found = append(found, match[group])
+		}
+	}
+
+	var values []string
+	for _, value := range found {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	switch {
+	case len(values) == 0:
+		if f.Default == nil {
+			return nil
+		}
+		return f.wrap(f.Default)
+	case f.Multiple:
+		return values
+	default:
+		return values[0]
+	}
+}
+
+// wrap puts a scalar constant or default into an array for fields with
+// multiple values, so that the field has the same type on every document.
+func (f CustomField) wrap(value any) any {
+	if _, isArray := value.([]any); f.Multiple && !isArray {
+		return []any{value}
+	}
+	return value
+}
+
+// MarshalJSON writes the built-in fields followed by the custom fields in
+// name order.
+func (d Document) MarshalJSON() ([]byte, error) {
+	type document Document
+	data, err := json.Marshal(document(d))
+	if err != nil || len(d.Custom) == 0 {
+		return data, err
+	}
+
+	names := make([]string, 0, len(d.Custom))
+	for name := range d.Custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for _, name := range names {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(d.Custom[name])
+		if err != nil {
+			return nil, fmt.Errorf("custom field %s: %w", name, err)
+		}
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
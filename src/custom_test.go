@@ -0,0 +1,97 @@
+package src
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateCustomFields(t *testing.T) {
+	tests := []struct {
+		fields  string
+		wantErr bool
+	}{
+		{`{"version": {"selector": ".version"}}`, false},
+		{`{"version": {"selector": "a.version", "attribute": "href"}}`, false},
+		{`{"locale": {"meta": "og:locale"}}`, false},
+		{`{"lang": {"meta": "og:locale"}}`, true},
+		{`{"version": {"url_pattern": "/v([0-9]+)/"}}`, false},
+		{`{"version": {"url_pattern": "/(docs)/v([0-9]+)/", "group": 2}}`, false},
+		{`{"product": {"value": "cli"}}`, false},
+		{`{"version": {}}`, true},
+		{`{"version": {"selector": ".version", "meta": "version"}}`, true},
+		{`{"version": {"meta": "version", "attribute": "content"}}`, true},
+		{`{"version": {"selector": "div["}}`, true},
+		{`{"version": {"url_pattern": "("}}`, true},
+		{`{"version": {"url_pattern": "/v([0-9]+)/", "group": 2}}`, true},
+		{`{"content": {"value": "x"}}`, true},
+		{`{"": {"value": "x"}}`, true},
+	}
+
+	for _, tt := range tests {
+		var fields map[string]CustomField
+		if err := json.Unmarshal([]byte(tt.fields), &fields); err != nil {
+			t.Fatal(err)
+		}
+		if err := validateCustomFields(fields); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateCustomFields() error = %v, wantErr %v", tt.fields, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExtractCustomFields(t *testing.T) {
+	goDoc := parseHTML(t, `<html><head><meta property="og:locale" content="en_US"></head><body>
+<span class="version"> 2.1 </span>
+<a class="tag" href="/tags/cli">CLI</a><a class="tag" href="/tags/api">API</a><a class="tag" href="">Empty</a>
+</body></html>`)
+	pageURL := "https://docs.example.com/v2/guide"
+
+	tests := []struct {
+		name  string
+		field string
+		want  any
+	}{
+		{"selector text", `{"selector": ".version"}`, "2.1"},
+		{"first match only", `{"selector": ".tag"}`, "CLI"},
+		{"attribute of every match", `{"selector": ".tag", "attribute": "href", "multiple": true}`, []string{"/tags/cli", "/tags/api"}},
+		{"meta property", `{"meta": "og:locale"}`, "en_US"},
+		{"url pattern group", `{"url_pattern": "/v([0-9]+)/"}`, "2"},
+		{"url pattern whole match", `{"url_pattern": "guide$"}`, "guide"},
+		{"constant value", `{"value": 3}`, float64(3)},
+		{"constant wrapped for multiple", `{"value": "cli", "multiple": true}`, []any{"cli"}},
+		{"constant array kept", `{"value": ["a", "b"], "multiple": true}`, []any{"a", "b"}},
+		{"default", `{"selector": ".missing", "default": "latest"}`, "latest"},
+		{"default wrapped for multiple", `{"selector": ".missing", "default": "latest", "multiple": true}`, []any{"latest"}},
+		{"no match without default", `{"selector": ".missing"}`, nil},
+	}
+
+	for _, tt := range tests {
+		var field CustomField
+		if err := json.Unmarshal([]byte(tt.field), &field); err != nil {
+			t.Fatal(err)
+		}
+		if got := field.extract(pageURL, goDoc); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDocumentMarshalJSONCustomFields(t *testing.T) {
+	doc := newDocument(testPageURL, "", hierarchy{}, "text", "")
+	doc.Custom = map[string]any{"version": "2", "tags": []string{"a"}}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	if got["version"] != "2" || !reflect.DeepEqual(got["tags"], []any{"a"}) {
+		t.Errorf("custom fields missing from %s", data)
+	}
+	if got["content"] != "text" {
+		t.Errorf("built-in fields missing from %s", data)
+	}
+}
@@ -0,0 +1,408 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// FormatDocSearch marks configs imported from the DocSearch scraper format.
+const FormatDocSearch = "docsearch"
+
+// docSearchKeys are top-level keys that only appear in DocSearch scraper
+// configs, never in the native format.
+var docSearchKeys = []string{"index_name", "start_urls", "sitemap_urls", "stop_urls", "custom_settings"}
+
+func isDocSearchConfig(raw map[string]json.RawMessage) bool {
+	for _, key := range docSearchKeys {
+		if _, ok := raw[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// docSearchImporter collects the mapped config and warnings while converting
+// a DocSearch config.
+type docSearchImporter struct {
+	config   Config
+	warnings []string
+
+	// setURLs maps selector set names to the start URLs using them.
+	setURLs map[string][]string
+}
+
+func (d *docSearchImporter) warn(format string, args ...interface{}) {
+	d.warnings = append(d.warnings, fmt.Sprintf(format, args...))
+}
+
+func parseDocSearchConfig(raw map[string]json.RawMessage) (*Config, []string, error) {
+	d := &docSearchImporter{setURLs: make(map[string][]string)}
+	d.config.Format = FormatDocSearch
+	d.config.RecordGranularity = GranularityText
+	// DocSearch fetches pages exactly as listed, without the default suffix.
+	d.config.URLRewrite.Mode = RewriteNone
+
+	var startURLs []string
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := raw[key]
+		var err error
+
+		switch key {
+		case "index_name":
+			err = json.Unmarshal(value, &d.config.IndexName)
+		case "start_urls":
+			startURLs, err = d.parseStartURLs(value)
+		case "sitemap_urls":
+			err = json.Unmarshal(value, &d.config.Sitemaps)
+		case "stop_urls":
+			var stopURLs []string
+			if err = json.Unmarshal(value, &stopURLs); err == nil {
+				for _, stop := range stopURLs {
+					d.config.URLs.Exclude = append(d.config.URLs.Exclude, regexPrefix+stop)
+				}
+			}
+		case "selectors":
+			err = d.parseSelectors(value)
+		case "min_indexed_level":
+			err = json.Unmarshal(value, &d.config.MinIndexedLevel)
+		case "allowed_domains":
+			var domains []string
+			if err = json.Unmarshal(value, &domains); err == nil {
+				for _, domain := range domains {
+					d.config.Crawl.AllowedPrefixes = append(d.config.Crawl.AllowedPrefixes, domain+"/")
+				}
+			}
+		case "user_agent":
+			err = json.Unmarshal(value, &d.config.HTTP.UserAgent)
+		case "selectors_exclude":
+			err = json.Unmarshal(value, &d.config.SelectorsExclude)
+		case "custom_settings":
+			err = d.parseCustomSettings(value)
+		default:
+			d.warn("field %q is not supported and is ignored", key)
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid DocSearch field %q: %w", key, err)
+		}
+	}
+
+	// DocSearch only indexes pages below a start URL. With sitemaps, the
+	// start URLs restrict the sitemap entries; without, they seed the crawl.
+	if len(d.config.Sitemaps) > 0 {
+		for _, start := range startURLs {
+			d.config.URLs.Include = append(d.config.URLs.Include, start+"*")
+		}
+		// Allowed domains only restrict which links a crawl follows.
+		if len(d.config.Crawl.AllowedPrefixes) > 0 {
+			d.warn("field %q only applies when crawling and is ignored with %q", "allowed_domains", "sitemap_urls")
+		}
+	} else {
+		d.config.Crawl.StartURLs = startURLs
+		if len(d.config.Crawl.AllowedPrefixes) == 0 {
+			d.config.Crawl.AllowedPrefixes = startURLs
+		}
+	}
+
+	// Named selector sets apply to pages below the start URLs that reference
+	// them through selectors_key.
+	var sets []SelectorSet
+	for _, set := range d.config.SelectorSets {
+		urls := d.setURLs[set.Name]
+		if len(urls) == 0 {
+			d.warn("selector set %q is not referenced by any start URL and is ignored", set.Name)
+			continue
+		}
+		for _, u := range urls {
+			set.URLPatterns = append(set.URLPatterns, u+"*")
+		}
+		sets = append(sets, set)
+	}
+
+	// The first matching set wins, so more specific start URLs go first.
+	sort.SliceStable(sets, func(i, j int) bool {
+		return longestPattern(sets[i]) > longestPattern(sets[j])
+	})
+	d.config.SelectorSets = sets
+
+	setNames := make([]string, 0, len(d.setURLs))
+	for name := range d.setURLs {
+		setNames = append(setNames, name)
+	}
+	sort.Strings(setNames)
+	for _, name := range setNames {
+		if name != DefaultSelectorSet && !hasSelectorSet(sets, name) {
+			d.warn("selectors_key %q does not name a selector set; the default set is used", name)
+		}
+	}
+
+	return &d.config, d.warnings, nil
+}
+
+func longestPattern(set SelectorSet) int {
+	longest := 0
+	for _, pattern := range set.URLPatterns {
+		if len(pattern) > longest {
+			longest = len(pattern)
+		}
+	}
+	return longest
+}
+
+func hasSelectorSet(sets []SelectorSet, name string) bool {
+	for _, set := range sets {
+		if set.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStartURLs accepts start URLs given as plain strings or as objects.
+func (d *docSearchImporter) parseStartURLs(value json.RawMessage) ([]string, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(value, &entries); err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	for _, entry := range entries {
+		var plain string
+		if err := json.Unmarshal(entry, &plain); err == nil {
+			urls = append(urls, plain)
+			continue
+		}
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(entry, &object); err != nil {
+			return nil, err
+		}
+		var startURL string
+		if err := json.Unmarshal(object["url"], &startURL); err != nil {
+			return nil, fmt.Errorf("start URL object without url: %w", err)
+		}
+		urls = append(urls, startURL)
+
+		if key, ok := object["selectors_key"]; ok {
+			var name string
+			if err := json.Unmarshal(key, &name); err != nil {
+				return nil, fmt.Errorf("selectors_key of %s: %w", startURL, err)
+			}
+			d.setURLs[name] = append(d.setURLs[name], startURL)
+		}
+
+		options := make([]string, 0, len(object))
+		for option := range object {
+			if option != "url" && option != "selectors_key" {
+				options = append(options, option)
+			}
+		}
+		sort.Strings(options)
+		for _, option := range options {
+			d.warn("start_urls option %q of %s is not supported and is ignored", option, startURL)
+		}
+	}
+
+	for _, u := range urls {
+		if parsed, err := url.Parse(u); err != nil || parsed.Host == "" {
+			d.warn("start URL %q is not an absolute URL; DocSearch regex start URLs are not supported", u)
+		}
+	}
+
+	return urls, nil
+}
+
+// parseCustomSettings maps the index settings of a DocSearch config, given
+// with Meilisearch (or Algolia) setting names, onto index_settings.
+func (d *docSearchImporter) parseCustomSettings(value json.RawMessage) error {
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(value, &settings); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	target := &d.config.IndexSettings
+	for _, key := range keys {
+		value := settings[key]
+		var err error
+
+		switch key {
+		case "searchableAttributes":
+			err = json.Unmarshal(value, &target.SearchableAttributes)
+		case "displayedAttributes", "attributesToRetrieve":
+			err = json.Unmarshal(value, &target.DisplayedAttributes)
+		case "rankingRules":
+			err = json.Unmarshal(value, &target.RankingRules)
+		case "distinctAttribute", "attributeForDistinct":
+			err = json.Unmarshal(value, &target.DistinctAttribute)
+		case "filterableAttributes":
+			err = json.Unmarshal(value, &target.FilterableAttributes)
+		case "attributesForFaceting":
+			// Algolia wraps attributes in filterOnly(...) or searchable(...).
+			var attributes []string
+			if err = json.Unmarshal(value, &attributes); err == nil {
+				for _, attribute := range attributes {
+					if open := strings.Index(attribute, "("); open >= 0 && strings.HasSuffix(attribute, ")") {
+						attribute = attribute[open+1 : len(attribute)-1]
+					}
+					target.FilterableAttributes = append(target.FilterableAttributes, attribute)
+				}
+			}
+		case "sortableAttributes":
+			err = json.Unmarshal(value, &target.SortableAttributes)
+		case "synonyms":
+			if err = json.Unmarshal(value, &target.Synonyms); err != nil {
+				d.warn("custom_settings.synonyms is not a map of word lists and is ignored")
+				err = nil
+			}
+		case "stopWords":
+			err = json.Unmarshal(value, &target.StopWords)
+		default:
+			d.warn("custom_settings.%s is not supported and is ignored", key)
+		}
+
+		if err != nil {
+			return fmt.Errorf("custom_settings.%s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// parseSelectors maps either a flat selector set, or named selector sets where
+// "default" becomes the top-level selectors.
+func (d *docSearchImporter) parseSelectors(value json.RawMessage) error {
+	var sets map[string]json.RawMessage
+	if err := json.Unmarshal(value, &sets); err != nil {
+		return err
+	}
+
+	if _, ok := sets["default"]; !ok {
+		return d.parseSelectorSet("default", value, &d.config.Selectors)
+	}
+
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "default" {
+			continue
+		}
+		set := SelectorSet{Name: name}
+		if err := d.parseSelectorSet(name, sets[name], &set.Selectors); err != nil {
+			return err
+		}
+		d.config.SelectorSets = append(d.config.SelectorSets, set)
+	}
+
+	return d.parseSelectorSet("default", sets["default"], &d.config.Selectors)
+}
+
+func (d *docSearchImporter) parseSelectorSet(name string, value json.RawMessage, selectors *Selectors) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		selector, err := d.parseSelector(name, key, fields[key])
+		if err != nil {
+			return fmt.Errorf("selector %s.%s: %w", name, key, err)
+		}
+
+		switch key {
+		case "lvl0":
+			selectors.Lvl0 = selector
+		case "lvl1":
+			selectors.Lvl1 = d.plainSelector(name, key, selector)
+		case "lvl2":
+			selectors.Lvl2 = d.plainSelector(name, key, selector)
+		case "lvl3":
+			selectors.Lvl3 = d.plainSelector(name, key, selector)
+		case "lvl4":
+			selectors.Lvl4 = d.plainSelector(name, key, selector)
+		case "lvl5":
+			selectors.Lvl5 = d.plainSelector(name, key, selector)
+		case "lvl6":
+			selectors.Lvl6 = d.plainSelector(name, key, selector)
+		case "text":
+			selectors.Text = d.plainSelector(name, key, selector)
+		default:
+			d.warn("selector %s.%s is not supported and is ignored", name, key)
+		}
+	}
+
+	return nil
+}
+
+// parseSelector reads a selector given as a string or as an object.
+func (d *docSearchImporter) parseSelector(set, key string, value json.RawMessage) (SelectorConfig, error) {
+	var plain string
+	if err := json.Unmarshal(value, &plain); err == nil {
+		return SelectorConfig{Selector: plain}, nil
+	}
+
+	var object struct {
+		Selector     string          `json:"selector"`
+		Global       bool            `json:"global"`
+		DefaultValue string          `json:"default_value"`
+		Type         string          `json:"type"`
+		StripChars   string          `json:"strip_chars"`
+		Attributes   json.RawMessage `json:"attributes"`
+	}
+	if err := json.Unmarshal(value, &object); err != nil {
+		return SelectorConfig{}, err
+	}
+
+	if object.Type != "" && !strings.EqualFold(object.Type, "css") {
+		d.warn("selector %s.%s uses type %q; only CSS selectors are supported, so it is ignored", set, key, object.Type)
+		return SelectorConfig{}, nil
+	}
+	if object.StripChars != "" {
+		d.warn("selector %s.%s: strip_chars is not supported and is ignored", set, key)
+	}
+	if len(object.Attributes) > 0 {
+		d.warn("selector %s.%s: attributes is not supported and is ignored", set, key)
+	}
+
+	return SelectorConfig{
+		Selector:     object.Selector,
+		Global:       object.Global,
+		DefaultValue: object.DefaultValue,
+	}, nil
+}
+
+// plainSelector returns the CSS selector of a level other than lvl0, which
+// only supports global and default_value for lvl0.
+func (d *docSearchImporter) plainSelector(set, key string, selector SelectorConfig) string {
+	if selector.Global {
+		d.warn("selector %s.%s: global is only supported for lvl0 and is ignored", set, key)
+	}
+	if selector.DefaultValue != "" {
+		d.warn("selector %s.%s: default_value is only supported for lvl0 and is ignored", set, key)
+	}
+	return selector.Selector
+}
@@ -0,0 +1,220 @@
+package src
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfigFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"native", `{"sitemaps": ["https://docs.example.com/sitemap.xml"]}`, ""},
+		{"index_name", `{"index_name": "docs"}`, FormatDocSearch},
+		{"start_urls", `{"start_urls": ["https://docs.example.com/"]}`, FormatDocSearch},
+		{"sitemap_urls", `{"sitemap_urls": ["https://docs.example.com/sitemap.xml"]}`, FormatDocSearch},
+	}
+
+	for _, tt := range tests {
+		config, _, err := ParseConfig([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if config.Format != tt.want {
+			t.Errorf("%s: got format %q, want %q", tt.name, config.Format, tt.want)
+		}
+	}
+}
+
+func TestParseDocSearchConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		check func(t *testing.T, config *Config)
+		warns []string
+	}{
+		{
+			name: "defaults",
+			data: `{"index_name": "docs"}`,
+			check: func(t *testing.T, config *Config) {
+				if config.IndexName != "docs" {
+					t.Errorf("got index name %q, want %q", config.IndexName, "docs")
+				}
+				if config.RecordGranularity != GranularityText {
+					t.Errorf("got granularity %q, want %q", config.RecordGranularity, GranularityText)
+				}
+				if config.URLRewrite.Mode != RewriteNone {
+					t.Errorf("got URL rewrite mode %q, want %q", config.URLRewrite.Mode, RewriteNone)
+				}
+			},
+		},
+		{
+			name: "start URLs seed the crawl",
+			data: `{"start_urls": ["https://docs.example.com/guide/", {"url": "https://docs.example.com/api/"}]}`,
+			check: func(t *testing.T, config *Config) {
+				want := []string{"https://docs.example.com/guide/", "https://docs.example.com/api/"}
+				if !reflect.DeepEqual(config.Crawl.StartURLs, want) {
+					t.Errorf("got start URLs %v, want %v", config.Crawl.StartURLs, want)
+				}
+				if !reflect.DeepEqual(config.Crawl.AllowedPrefixes, want) {
+					t.Errorf("got allowed prefixes %v, want %v", config.Crawl.AllowedPrefixes, want)
+				}
+			},
+		},
+		{
+			name: "allowed domains replace the start URL prefixes",
+			data: `{"start_urls": ["https://docs.example.com/guide/"], "allowed_domains": ["docs.example.com"]}`,
+			check: func(t *testing.T, config *Config) {
+				want := []string{"docs.example.com/"}
+				if !reflect.DeepEqual(config.Crawl.AllowedPrefixes, want) {
+					t.Errorf("got allowed prefixes %v, want %v", config.Crawl.AllowedPrefixes, want)
+				}
+			},
+		},
+		{
+			name: "start URLs restrict sitemap entries",
+			data: `{"sitemap_urls": ["https://docs.example.com/sitemap.xml"], "start_urls": ["https://docs.example.com/guide/"], "allowed_domains": ["docs.example.com"]}`,
+			check: func(t *testing.T, config *Config) {
+				if len(config.Crawl.StartURLs) != 0 {
+					t.Errorf("got crawl start URLs %v, want none", config.Crawl.StartURLs)
+				}
+				want := []string{"https://docs.example.com/guide/*"}
+				if !reflect.DeepEqual(config.URLs.Include, want) {
+					t.Errorf("got include rules %v, want %v", config.URLs.Include, want)
+				}
+			},
+			warns: []string{`"allowed_domains" only applies when crawling`},
+		},
+		{
+			name: "stop URLs become regex exclude rules",
+			data: `{"start_urls": ["https://docs.example.com/"], "stop_urls": ["/blog/", "\\?page="]}`,
+			check: func(t *testing.T, config *Config) {
+				want := []string{"regex:/blog/", `regex:\?page=`}
+				if !reflect.DeepEqual(config.URLs.Exclude, want) {
+					t.Errorf("got exclude rules %v, want %v", config.URLs.Exclude, want)
+				}
+			},
+		},
+		{
+			name: "flat selectors",
+			data: `{"index_name": "docs", "selectors": {"lvl0": {"selector": ".product", "global": true, "default_value": "Docs"}, "lvl1": "h1", "text": "p, li"}}`,
+			check: func(t *testing.T, config *Config) {
+				want := Selectors{
+					Lvl0: SelectorConfig{Selector: ".product", Global: true, DefaultValue: "Docs"},
+					Lvl1: "h1",
+					Text: "p, li",
+				}
+				if !reflect.DeepEqual(config.Selectors, want) {
+					t.Errorf("got selectors %+v, want %+v", config.Selectors, want)
+				}
+			},
+		},
+		{
+			name: "named selector sets bind to their start URLs",
+			data: `{
+				"start_urls": [
+					{"url": "https://docs.example.com/", "selectors_key": "guide"},
+					{"url": "https://docs.example.com/api/", "selectors_key": "api"},
+					{"url": "https://docs.example.com/blog/", "selectors_key": "blog"}
+				],
+				"selectors": {
+					"default": {"lvl1": "h1", "text": "p"},
+					"guide": {"lvl1": ".guide h1", "text": ".guide p"},
+					"api": {"lvl1": ".api h1", "text": ".api p"},
+					"unused": {"lvl1": "h1"}
+				}
+			}`,
+			check: func(t *testing.T, config *Config) {
+				var got []string
+				for _, set := range config.SelectorSets {
+					got = append(got, set.Name+" "+strings.Join(set.URLPatterns, ","))
+				}
+				want := []string{"api https://docs.example.com/api/*", "guide https://docs.example.com/*"}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("got selector sets %v, want %v", got, want)
+				}
+				if config.Selectors.Lvl1 != "h1" {
+					t.Errorf("got default lvl1 %q, want %q", config.Selectors.Lvl1, "h1")
+				}
+			},
+			warns: []string{
+				`selector set "unused" is not referenced`,
+				`selectors_key "blog" does not name a selector set`,
+			},
+		},
+		{
+			name: "custom settings",
+			data: `{"index_name": "docs", "custom_settings": {"attributesForFaceting": ["filterOnly(lang)", "version"], "attributeForDistinct": "url", "synonyms": ["not", "a", "map"]}}`,
+			check: func(t *testing.T, config *Config) {
+				settings := config.IndexSettings
+				if want := []string{"lang", "version"}; !reflect.DeepEqual(settings.FilterableAttributes, want) {
+					t.Errorf("got filterable attributes %v, want %v", settings.FilterableAttributes, want)
+				}
+				if settings.DistinctAttribute == nil || *settings.DistinctAttribute != "url" {
+					t.Errorf("got distinct attribute %v, want url", settings.DistinctAttribute)
+				}
+			},
+			warns: []string{"custom_settings.synonyms is not a map"},
+		},
+		{
+			name: "unsupported fields and options warn",
+			data: `{"index_name": "docs", "js_render": true, "start_urls": [{"url": "https://docs.example.com/", "page_rank": 5}], "selectors": {"lvl1": {"selector": "//h1", "type": "xpath"}, "lvl2": {"selector": "h2", "global": true}}}`,
+			warns: []string{
+				`field "js_render" is not supported`,
+				`start_urls option "page_rank" of https://docs.example.com/ is not supported`,
+				`selector default.lvl1 uses type "xpath"`,
+				`selector default.lvl2: global is only supported for lvl0`,
+			},
+		},
+		{
+			name:  "regex start URLs warn",
+			data:  `{"start_urls": ["https://docs.example.com/", "(?P<lang>.*?)/docs/"]}`,
+			warns: []string{`start URL "(?P<lang>.*?)/docs/" is not an absolute URL`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, warnings, err := ParseConfig([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.check != nil {
+				tt.check(t, config)
+			}
+			if len(warnings) != len(tt.warns) {
+				t.Errorf("got warnings %q, want %d", warnings, len(tt.warns))
+			}
+			for _, want := range tt.warns {
+				found := false
+				for _, warning := range warnings {
+					if strings.Contains(warning, want) {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("no warning contains %q; got %q", want, warnings)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDocSearchConfigErrors(t *testing.T) {
+	tests := []string{
+		`{"index_name": 5}`,
+		`{"start_urls": [{"selectors_key": "api"}]}`,
+		`{"index_name": "docs", "selectors": {"lvl1": 1}}`,
+		`{"index_name": "docs", "custom_settings": {"rankingRules": "words"}}`,
+	}
+
+	for _, data := range tests {
+		if _, _, err := ParseConfig([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", data)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package src
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// regexPrefix marks a URL rule as a regular expression instead of a glob.
+const regexPrefix = "regex:"
+
+// URLFilter decides which URLs are scraped based on include and exclude
+// rules. Rules are globs matched against the full URL, where "*" matches any
+// sequence of characters and "?" a single one, or regular expressions when
+// prefixed with "regex:".
+type URLFilter struct {
+	include []urlRule
+	exclude []urlRule
+}
+
+type urlRule struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// RuleCount is the number of URLs a single rule removed.
+type RuleCount struct {
+	Pattern string
+	Removed int
+}
+
+// FilterReport summarises how many URLs each rule removed.
+type FilterReport struct {
+	NotIncluded int
+	Excluded    []RuleCount
+}
+
+func NewURLFilter(include, exclude []string) (*URLFilter, error) {
+	f := &URLFilter{}
+
+	for _, pattern := range include {
+		rule, err := compileURLRule(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, rule)
+	}
+	for _, pattern := range exclude {
+		rule, err := compileURLRule(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, rule)
+	}
+
+	return f, nil
+}
+
+func compileURLRule(pattern string) (urlRule, error) {
+	if expr, ok := strings.CutPrefix(pattern, regexPrefix); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return urlRule{}, fmt.Errorf("invalid URL rule %q: %w", pattern, err)
+		}
+		return urlRule{pattern: pattern, re: re}, nil
+	}
+
+	return urlRule{pattern: pattern, re: regexp.MustCompile(globToRegexp(pattern))}, nil
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// Empty reports whether the filter has no rules.
+func (f *URLFilter) Empty() bool {
+	return len(f.include) == 0 && len(f.exclude) == 0
+}
+
+// HasInclude reports whether the filter has include rules.
+func (f *URLFilter) HasInclude() bool {
+	return len(f.include) > 0
+}
+
+// Match reports whether a URL passes the filter: it matches at least one
+// include rule (if there are any) and no exclude rule.
+func (f *URLFilter) Match(url string) bool {
+	return f.included(url) && f.excludedBy(url) < 0
+}
+
+func (f *URLFilter) included(url string) bool {
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, rule := range f.include {
+		if rule.re.MatchString(url) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedBy returns the index of the first exclude rule matching url, or -1.
+func (f *URLFilter) excludedBy(url string) int {
+	for i, rule := range f.exclude {
+		if rule.re.MatchString(url) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Apply filters urls and reports how many URLs each rule removed. A URL
+// matching several exclude rules is counted for the first one only.
+func (f *URLFilter) Apply(urls []URL) ([]URL, FilterReport) {
+	report := FilterReport{Excluded: make([]RuleCount, len(f.exclude))}
+	for i, rule := range f.exclude {
+		report.Excluded[i].Pattern = rule.pattern
+	}
+
+	var filtered []URL
+	for _, u := range urls {
+		if !f.included(u.Loc) {
+			report.NotIncluded++
+			continue
+		}
+		if i := f.excludedBy(u.Loc); i >= 0 {
+			report.Excluded[i].Removed++
+			continue
+		}
+		filtered = append(filtered, u)
+	}
+
+	return filtered, report
+}
@@ -0,0 +1,231 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// hierarchy holds the current heading text of each level, lvl0 to lvl6.
+type hierarchy [7]*string
+
+// set records a heading of the given level and clears all deeper levels.
+func (h *hierarchy) set(level int, text string) {
+	h[level] = &text
+	for deeper := level + 1; deeper < len(h); deeper++ {
+		h[deeper] = nil
+	}
+}
+
+// record is one heading (or the content preceding the first heading) with
+// the text collected below it.
+type record struct {
+	level     int
+	anchor    string
+	hierarchy hierarchy
+	content   []string
+	code      []CodeBlock
+
+	// row marks a record holding a single table row, and idSuffix tells
+	// rows of the same section apart. at is the number of content parts its
+	// section had when the table was found, which keeps rows in page order.
+	row      bool
+	idSuffix string
+	at       int
+}
+
+// extractRecords walks the page in document order, tracking the current
+// lvl0 to lvl6 headings, and returns one record per heading with the text
+// that follows it up to the next heading. Text before the first heading ends
+// up in a page-level record. Code blocks are collected separately and, if
+// configured, left out of the text. In rows mode, tables inside text matches
+// become one text part per row, or one record per row for large tables.
+func extractRecords(goDoc *goquery.Document, selectors *Selectors, code CodeConfig) []*record {
+	var current hierarchy
+
+	// Extract global lvl0 if configured
+	if selectors.Lvl0.Global {
+		text := strings.TrimSpace(goDoc.Find(selectors.Lvl0.Selector).First().Text())
+		if text == "" && selectors.Lvl0.DefaultValue != "" {
+			text = selectors.Lvl0.DefaultValue
+		}
+		if text != "" {
+			current.set(0, text)
+		}
+	}
+
+	levels := make(map[*html.Node]int)
+	levelSelectors := []string{"", selectors.Lvl1, selectors.Lvl2, selectors.Lvl3, selectors.Lvl4, selectors.Lvl5, selectors.Lvl6}
+	if !selectors.Lvl0.Global {
+		levelSelectors[0] = selectors.Lvl0.Selector
+	}
+	for level, selector := range levelSelectors {
+		if selector == "" {
+			continue
+		}
+		goDoc.Find(selector).Each(func(i int, s *goquery.Selection) {
+			node := s.Get(0)
+			if _, ok := levels[node]; !ok {
+				levels[node] = level
+			}
+		})
+	}
+
+	textNodes := make(map[*html.Node]bool)
+	if selectors.Text != "" {
+		goDoc.Find(selectors.Text).Each(func(i int, s *goquery.Selection) {
+			textNodes[s.Get(0)] = true
+		})
+	}
+
+	codeNodes := make(map[*html.Node]bool)
+	goDoc.Find(code.selector()).Each(func(i int, s *goquery.Selection) {
+		codeNodes[s.Get(0)] = true
+	})
+
+	// skip holds the elements whose text is not part of the text matches
+	// containing them.
+	skip := make(map[*html.Node]bool)
+	if code.ExcludeFromContent {
+		for node := range codeNodes {
+			skip[node] = true
+		}
+	}
+	tableNodes := make(map[*html.Node]bool)
+	if selectors.Tables.Mode == TableModeRows {
+		goDoc.Find("table").Each(func(i int, s *goquery.Selection) {
+			if node := s.Get(0); handledTable(node, textNodes, levels) {
+				tableNodes[node] = true
+				skip[node] = true
+			}
+		})
+	}
+
+	records := []*record{{level: -1, hierarchy: current}}
+	section := records[0]
+	usedAnchors := make(map[string]bool)
+	rowCount := 0
+
+	goDoc.Find("*").Each(func(i int, s *goquery.Selection) {
+		node := s.Get(0)
+
+		if level, ok := levels[node]; ok {
+			text := strings.TrimSpace(s.Text())
+			if text == "" {
+				return
+			}
+			current.set(level, text)
+
+			// Headings without an id below lvl1 get a synthetic anchor, as
+			// does any heading whose anchor (or the bare page URL) is taken.
+			anchor := findAnchor(s)
+			pageRecord := len(records[0].content) > 0 || len(records[0].code) > 0
+			taken := usedAnchors[anchor] || (anchor == "" && pageRecord)
+			if (anchor == "" && level >= 2) || taken {
+				anchor = fmt.Sprintf("section_%d", len(records)-rowCount)
+			}
+			usedAnchors[anchor] = true
+
+			section = &record{level: level, anchor: anchor, hierarchy: current}
+			records = append(records, section)
+			return
+		}
+
+		if codeNodes[node] && !insideNode(node, codeNodes) {
+			if block, ok := newCodeBlock(s); ok {
+				section.code = append(section.code, block)
+			}
+		}
+
+		if tableNodes[node] {
+			rows := tableRows(s, skip)
+			if !selectors.Tables.rowRecords(len(rows)) {
+				for _, row := range rows {
+					section.content = append(section.content, row.text)
+				}
+				return
+			}
+			for _, row := range rows {
+				anchor := section.anchor
+				if row.id != "" && !usedAnchors[row.id] {
+					anchor = row.id
+					usedAnchors[anchor] = true
+				}
+				rowCount++
+				records = append(records, &record{
+					level:     section.level,
+					anchor:    anchor,
+					hierarchy: current,
+					content:   []string{row.text},
+					row:       true,
+					idSuffix:  fmt.Sprintf("|row%d", rowCount),
+					at:        len(section.content),
+				})
+			}
+			return
+		}
+
+		if !textNodes[node] || insideMatch(node, textNodes, levels) {
+			return
+		}
+		if skip[node] || insideNode(node, skip) {
+			return
+		}
+		text := s.Text()
+		if len(skip) > 0 {
+			text = textWithout(node, skip)
+		}
+		if text := strings.TrimSpace(text); text != "" {
+			section.content = append(section.content, text)
+		}
+	})
+
+	// The page-level record only matters when text or code precedes the
+	// first heading.
+	if len(records[0].content) == 0 && len(records[0].code) == 0 {
+		records = records[1:]
+	}
+
+	return records
+}
+
+// findAnchor returns the id a heading can be linked to: its own id, or the id
+// or name of an anchor inside it.
+func findAnchor(s *goquery.Selection) string {
+	if id, ok := s.Attr("id"); ok && id != "" {
+		return id
+	}
+	if id, ok := s.Find("[id]").First().Attr("id"); ok && id != "" {
+		return id
+	}
+	if name, ok := s.Find("a[name]").First().Attr("name"); ok && name != "" {
+		return name
+	}
+	return ""
+}
+
+// insideMatch reports whether an ancestor of node is itself a text or
+// heading match, whose text already includes the text of node.
+func insideMatch(node *html.Node, textNodes map[*html.Node]bool, levels map[*html.Node]int) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if textNodes[parent] {
+			return true
+		}
+		if _, ok := levels[parent]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// insideNode reports whether an ancestor of node is one of nodes.
+func insideNode(node *html.Node, nodes map[*html.Node]bool) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if nodes[parent] {
+			return true
+		}
+	}
+	return false
+}
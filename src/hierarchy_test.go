@@ -0,0 +1,230 @@
+package src
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testPageURL = "https://docs.example.com/page"
+
+// testSelectors are the selectors most tests use: h1 to h3 and paragraphs.
+var testSelectors = Selectors{Lvl1: "h1", Lvl2: "h2", Lvl3: "h3", Text: "p"}
+
+func parseHTML(t *testing.T, body string) *goquery.Document {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// describeRecord formats a record as "level anchor (lvl0 > lvl1 ...): content".
+func describeRecord(r *record) string {
+	var path []string
+	for _, text := range r.hierarchy {
+		if text != nil {
+			path = append(path, *text)
+		}
+	}
+	return fmt.Sprintf("%d %s (%s): %s", r.level, r.anchor, strings.Join(path, " > "), strings.Join(r.content, " | "))
+}
+
+func TestExtractRecords(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "heading ids become anchors",
+			body: `<h1 id="intro">Intro</h1><p>a</p><h2 id="setup">Setup</h2><p>b</p><p>c</p>`,
+			want: []string{
+				"1 intro (Intro): a",
+				"2 setup (Intro > Setup): b | c",
+			},
+		},
+		{
+			name: "anchor from a nested id or name",
+			body: `<h2><a id="nested"></a>Nested</h2><p>a</p><h2><a name="named">Named</a></h2><p>b</p>`,
+			want: []string{
+				"2 nested (Nested): a",
+				"2 named (Named): b",
+			},
+		},
+		{
+			name: "headings without an id below lvl1 get a synthetic anchor",
+			body: `<h1>Title</h1><p>a</p><h2>Section</h2><p>b</p><h3>Sub</h3><p>c</p>`,
+			want: []string{
+				"1  (Title): a",
+				"2 section_2 (Title > Section): b",
+				"3 section_3 (Title > Section > Sub): c",
+			},
+		},
+		{
+			name: "duplicate ids get a synthetic anchor",
+			body: `<h1 id="a">A</h1><p>a</p><h2 id="a">B</h2><p>b</p><h2 id="b">C</h2><h3 id="b">D</h3>`,
+			want: []string{
+				"1 a (A): a",
+				"2 section_2 (A > B): b",
+				"2 b (A > C): ",
+				"3 section_4 (A > C > D): ",
+			},
+		},
+		{
+			name: "text before the first heading gets a page-level record",
+			body: `<p>intro</p><h1>Title</h1><p>a</p>`,
+			want: []string{
+				"-1  (): intro",
+				"1 section_1 (Title): a",
+			},
+		},
+		{
+			name: "deeper levels are cleared by a new heading",
+			body: `<h1>A</h1><h2>B</h2><h3>C</h3><h2>D</h2><p>d</p>`,
+			want: []string{
+				"1  (A): ",
+				"2 section_2 (A > B): ",
+				"3 section_3 (A > B > C): ",
+				"2 section_4 (A > D): d",
+			},
+		},
+		{
+			name: "empty headings are ignored",
+			body: `<h1>A</h1><h2> </h2><p>a</p>`,
+			want: []string{
+				"1  (A): a",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, r := range extractRecords(parseHTML(t, tt.body), &testSelectors, CodeConfig{}) {
+				got = append(got, describeRecord(r))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got records\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestExtractRecordsGlobalLvl0(t *testing.T) {
+	selectors := testSelectors
+	selectors.Lvl0 = SelectorConfig{Selector: ".product", Global: true, DefaultValue: "Docs"}
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`<div class="product">Guide</div><h1>A</h1><p>a</p>`, "1  (Guide > A): a"},
+		{`<h1>A</h1><p>a</p>`, "1  (Docs > A): a"},
+	}
+
+	for _, tt := range tests {
+		records := extractRecords(parseHTML(t, tt.body), &selectors, CodeConfig{})
+		if len(records) != 1 || describeRecord(records[0]) != tt.want {
+			var got []string
+			for _, r := range records {
+				got = append(got, describeRecord(r))
+			}
+			t.Errorf("%s: got records %q, want [%q]", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestExtractDocumentsGranularity(t *testing.T) {
+	body := `<p>intro</p><h1 id="a">A</h1><p>a1</p><p>a2</p><h2 id="b">B</h2><p>b</p>`
+
+	tests := []struct {
+		granularity string
+		want        []string
+	}{
+		{GranularityText, []string{
+			"content : intro",
+			"lvl1 a: ",
+			"content a: a1",
+			"content a: a2",
+			"lvl2 b: ",
+			"content b: b",
+		}},
+		{GranularitySection, []string{
+			"content : intro",
+			"lvl1 a: a1 a2",
+			"lvl2 b: b",
+		}},
+		{GranularityPage, []string{
+			"page : intro a1 a2 b",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.granularity, func(t *testing.T) {
+			config := &Config{RecordGranularity: tt.granularity}
+			docs := extractDocuments(testPageURL, parseHTML(t, body), &testSelectors, config)
+
+			var got []string
+			for i, doc := range docs {
+				if doc.Position != i {
+					t.Errorf("document %d has position %d", i, doc.Position)
+				}
+				content := ""
+				if doc.Content != nil {
+					content = *doc.Content
+				}
+				got = append(got, fmt.Sprintf("%s %s: %s", doc.Type, doc.Anchor, content))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got documents\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestExtractDocumentsMinIndexedLevel(t *testing.T) {
+	body := `<p>intro</p><h1 id="a">A</h1><p>a</p><h2 id="b">B</h2><p>b</p>`
+	config := &Config{RecordGranularity: GranularitySection, MinIndexedLevel: 2}
+
+	docs := extractDocuments(testPageURL, parseHTML(t, body), &testSelectors, config)
+	if len(docs) != 1 || docs[0].Anchor != "b" {
+		t.Fatalf("got %d documents, want only the lvl2 section", len(docs))
+	}
+	if docs[0].URL != testPageURL+"#b" {
+		t.Errorf("got URL %q, want %q", docs[0].URL, testPageURL+"#b")
+	}
+	if docs[0].HierarchyLvl1 == nil || *docs[0].HierarchyLvl1 != "A" {
+		t.Errorf("lvl2 section lost its lvl1 heading")
+	}
+}
+
+func TestExtractDocumentsUniqueObjectIDs(t *testing.T) {
+	pages := map[string]string{
+		"code before untitled heading": `<pre><code>x=1</code></pre><h1>T</h1><p>a</p>`,
+		"text before untitled heading": `<p>intro</p><h1>T</h1><p>a</p>`,
+		"duplicate heading ids":        `<h1 id="a">A</h1><p>a</p><h2 id="a">B</h2><p>b</p><h2 id="a">C</h2><p>c</p>`,
+		"headings without ids":         `<h1>A</h1><p>a</p><h2>B</h2><p>b</p><h2>C</h2><p>c</p>`,
+	}
+
+	for _, granularity := range []string{GranularityText, GranularitySection, GranularityPage} {
+		for name, body := range pages {
+			t.Run(granularity+"/"+name, func(t *testing.T) {
+				config := &Config{RecordGranularity: granularity}
+				docs := extractDocuments(testPageURL, parseHTML(t, body), &testSelectors, config)
+
+				seen := make(map[string]string)
+				for _, doc := range docs {
+					if other, ok := seen[doc.ObjectID]; ok {
+						t.Errorf("documents %s and %s share objectID %s", other, doc.URL, doc.ObjectID)
+					}
+					seen[doc.ObjectID] = doc.URL
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTP client defaults, used when the corresponding HTTPConfig field is zero.
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultReadTimeout    = 30 * time.Second
+	DefaultRetries        = 3
+	DefaultBackoffBase    = 500 * time.Millisecond
+	DefaultBackoffMax     = 30 * time.Second
+
+	// maxRetryAfter caps how long a Retry-After header can stall a request.
+	maxRetryAfter = 5 * time.Minute
+)
+
+// Duration is a time.Duration that unmarshals from JSON strings such as "10s"
+// or from a number of seconds.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", v, err)
+		}
+		*d = Duration(parsed)
+	default:
+		return fmt.Errorf("invalid duration: %s", data)
+	}
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d Duration) orDefault(def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return time.Duration(d)
+}
+
+// Client is the HTTP client shared by all sitemap, robots.txt and page
+// fetches. It applies timeouts, sets the User-Agent and configured
+// credentials, and retries failed requests with exponential backoff.
+type Client struct {
+	client      *http.Client
+	credentials *credentials
+	userAgent   string
+	retries     int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+}
+
+// DefaultClient is used for all fetches. Commands replace it with a client
+// built from the config file.
+var DefaultClient *Client
+
+func init() {
+	// The zero config has no secrets or cookie file, so it cannot fail.
+	DefaultClient, _ = NewClient(HTTPConfig{})
+}
+
+func NewClient(cfg HTTPConfig) (*Client, error) {
+	connectTimeout := cfg.ConnectTimeout.orDefault(DefaultConnectTimeout)
+	readTimeout := cfg.ReadTimeout.orDefault(DefaultReadTimeout)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.ResponseHeaderTimeout = readTimeout
+
+	retries := DefaultRetries
+	if cfg.Retries != nil {
+		retries = *cfg.Retries
+	}
+
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	creds, err := resolveCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport:     transport,
+		Timeout:       connectTimeout + readTimeout,
+		CheckRedirect: creds.checkRedirect,
+	}
+	if cfg.CookiesFile != "" {
+		jar, err := loadCookieJar(cfg.CookiesFile)
+		if err != nil {
+			return nil, err
+		}
+		client.Jar = jar
+	}
+
+	return &Client{
+		client:      client,
+		credentials: creds,
+		userAgent:   userAgent,
+		retries:     retries,
+		backoffBase: cfg.BackoffBase.orDefault(DefaultBackoffBase),
+		backoffMax:  cfg.BackoffMax.orDefault(DefaultBackoffMax),
+	}, nil
+}
+
+// Get fetches url, retrying on network errors, 429 and 5xx responses. The
+// last response is returned even if its status is still an error status, so
+// callers check the status code as usual.
+func (c *Client) Get(url string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", c.userAgent)
+		c.credentials.apply(req)
+
+		resp, err := c.client.Do(req)
+		if attempt >= c.retries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := c.backoff(attempt)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		log.Printf("Retrying %s in %s (attempt %d/%d): %s", url, delay.Round(time.Millisecond), attempt+1, c.retries, reason)
+		time.Sleep(delay)
+	}
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented)
+}
+
+// backoff returns the delay before retry number attempt+1: exponential growth
+// from backoffBase, capped at backoffMax, with random jitter in the upper half.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.backoffBase << attempt
+	if delay <= 0 || delay > c.backoffMax {
+		delay = c.backoffMax
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		delay = time.Until(t)
+	} else {
+		return 0, false
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return delay, true
+}
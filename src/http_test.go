@@ -0,0 +1,180 @@
+package src
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client with the given retries and a backoff short
+// enough for tests.
+func newTestClient(t *testing.T, retries int) *Client {
+	t.Helper()
+	client, err := NewClient(HTTPConfig{
+		Retries:     &retries,
+		BackoffBase: Duration(time.Millisecond),
+		BackoffMax:  Duration(5 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestClientGetRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retries      int
+		wantStatus   int
+		wantRequests int
+	}{
+		{"success", []int{200}, 3, 200, 1},
+		{"not found is not retried", []int{404, 200}, 3, 404, 1},
+		{"not implemented is not retried", []int{501, 200}, 3, 501, 1},
+		{"server error is retried", []int{500, 503, 200}, 3, 200, 3},
+		{"too many requests is retried", []int{429, 200}, 3, 200, 2},
+		{"last response after the retries", []int{502, 502, 502}, 2, 502, 3},
+		{"no retries", []int{503, 200}, 0, 503, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[min(requests, len(tt.statuses)-1)])
+				requests++
+			}))
+			defer server.Close()
+
+			resp, err := newTestClient(t, tt.retries).Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestClientGetRetriesNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	if _, err := newTestClient(t, 1).Get(serverURL); err == nil {
+		t.Error("expected an error from a closed server")
+	}
+}
+
+func TestClientGetRetryAfter(t *testing.T) {
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	resp, err := newTestClient(t, 1).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || len(times) != 2 {
+		t.Fatalf("got status %d after %d requests, want 200 after 2", resp.StatusCode, len(times))
+	}
+	// The backoff is at most 5ms, so only Retry-After explains the wait.
+	if waited := times[1].Sub(times[0]); waited < time.Second {
+		t.Errorf("retried after %s, want at least the 1s of Retry-After", waited)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"soon", 0, false},
+		{"0", 0, true},
+		{"30", 30 * time.Second, true},
+		{"-5", 0, true},
+		{"86400", maxRetryAfter, true},
+		{"Mon, 01 Jan 2001 00:00:00 GMT", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	// A date in the future waits until then.
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got, ok := parseRetryAfter(future); !ok || got <= 50*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %s, %v, want about a minute", future, got, ok)
+	}
+}
+
+func TestClientBackoff(t *testing.T) {
+	client := &Client{backoffBase: 100 * time.Millisecond, backoffMax: time.Second}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		{70, time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if got := client.backoff(tt.attempt); got < tt.max/2 || got > tt.max {
+				t.Errorf("backoff(%d) = %s, want between %s and %s", tt.attempt, got, tt.max/2, tt.max)
+			}
+		}
+	}
+}
+
+func TestDurationUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{`"10s"`, 10 * time.Second, false},
+		{`"1m30s"`, 90 * time.Second, false},
+		{`2.5`, 2500 * time.Millisecond, false},
+		{`"ten"`, 0, true},
+		{`true`, 0, true},
+	}
+
+	for _, tt := range tests {
+		var d Duration
+		err := json.Unmarshal([]byte(tt.data), &d)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && time.Duration(d) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.data, time.Duration(d), tt.want)
+		}
+	}
+}
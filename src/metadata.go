@@ -0,0 +1,81 @@
+package src
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PageMetadata is the metadata read from a page's <head>.
+type PageMetadata struct {
+	Title       string
+	Description string
+	Keywords    []string
+	OpenGraph   map[string]string
+	Canonical   string
+}
+
+// extractMetadata reads the title, description, keywords, Open Graph tags and
+// canonical URL of a page. A relative canonical URL is resolved against
+// pageURL.
+func extractMetadata(goDoc *goquery.Document, pageURL string) PageMetadata {
+	meta := PageMetadata{
+		Title:       strings.TrimSpace(goDoc.Find("head title").First().Text()),
+		Description: metaContent(goDoc, `meta[name="description" i]`),
+	}
+
+	for _, keyword := range strings.Split(metaContent(goDoc, `meta[name="keywords" i]`), ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			meta.Keywords = append(meta.Keywords, keyword)
+		}
+	}
+
+	goDoc.Find(`meta[property^="og:"]`).Each(func(i int, s *goquery.Selection) {
+		name := strings.TrimPrefix(s.AttrOr("property", ""), "og:")
+		content := strings.TrimSpace(s.AttrOr("content", ""))
+		if name == "" || content == "" {
+			return
+		}
+		if meta.OpenGraph == nil {
+			meta.OpenGraph = make(map[string]string)
+		}
+		// The first tag wins, as for repeated og:image tags.
+		if _, ok := meta.OpenGraph[name]; !ok {
+			meta.OpenGraph[name] = content
+		}
+	})
+
+	if href := strings.TrimSpace(goDoc.Find(`link[rel~="canonical"]`).First().AttrOr("href", "")); href != "" {
+		meta.Canonical = href
+		if base, err := url.Parse(pageURL); err == nil {
+			if ref, err := url.Parse(href); err == nil {
+				meta.Canonical = base.ResolveReference(ref).String()
+			}
+		}
+	}
+
+	return meta
+}
+
+func metaContent(goDoc *goquery.Document, selector string) string {
+	return strings.TrimSpace(goDoc.Find(selector).First().AttrOr("content", ""))
+}
+
+// apply copies the metadata onto every document of the page.
+func (m PageMetadata) apply(documents []Document) {
+	for i := range documents {
+		documents[i].Title = optionalString(m.Title)
+		documents[i].Description = optionalString(m.Description)
+		documents[i].Keywords = m.Keywords
+		documents[i].OpenGraph = m.OpenGraph
+		documents[i].Canonical = optionalString(m.Canonical)
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,137 @@
+package src
+
+import (
+	"log"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency is the number of pages scraped in parallel.
+const DefaultConcurrency = 4
+
+// DefaultRateLimit is the number of requests per second sent to a single host.
+const DefaultRateLimit = 5.0
+
+// HostLimiter spaces requests to the same host by the configured rate limit,
+// or by the host's robots.txt Crawl-delay when that is longer. A nil
+// *HostLimiter does not wait.
+type HostLimiter struct {
+	interval time.Duration
+	robots   *RobotsCache
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+// NewHostLimiter creates a limiter allowing requestsPerSecond requests per
+// host. A rate of zero or less disables the rate limit, leaving only the
+// robots.txt Crawl-delay.
+func NewHostLimiter(requestsPerSecond float64, robots *RobotsCache) *HostLimiter {
+	var interval time.Duration
+	if requestsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return &HostLimiter{
+		interval: interval,
+		robots:   robots,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// Wait blocks until a request to the host of rawURL may be sent.
+func (l *HostLimiter) Wait(rawURL string) {
+	if l == nil {
+		return
+	}
+
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+
+	interval := l.interval
+	if delay := l.robots.Delay(rawURL); delay > interval {
+		interval = delay
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(interval)
+	l.mu.Unlock()
+
+	time.Sleep(time.Until(slot))
+}
+
+// ScrapeOptions controls how ScrapeAll fetches pages.
+type ScrapeOptions struct {
+	Concurrency int
+	Limiter     *HostLimiter
+}
+
+// ScrapeResult is the outcome of scraping a single URL.
+type ScrapeResult struct {
+	Index int
+	URL   URL
+	*PageResult
+	Err error
+}
+
+// ScrapeAll scrapes urls with a pool of workers and calls handle for every
+// URL in input order, regardless of the order in which pages finish.
+func ScrapeAll(urls []URL, config *Config, opts ScrapeOptions, handle func(ScrapeResult)) {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan int)
+	results := make(chan ScrapeResult)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				page := urls[i]
+				opts.Limiter.Wait(page.Loc)
+				log.Printf("Scraping %d/%d: %s", i+1, len(urls), page.Loc)
+
+				result, err := ScrapePage(page, config)
+				results <- ScrapeResult{Index: i, URL: page, PageResult: result, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for i := range urls {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]ScrapeResult)
+	next := 0
+	for result := range results {
+		pending[result.Index] = result
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			handle(r)
+			next++
+		}
+	}
+}
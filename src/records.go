@@ -0,0 +1,216 @@
+package src
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Record granularities: one record per text node (DocSearch style), per
+// heading section, or per page.
+const (
+	GranularityText    = "text"
+	GranularitySection = "section"
+	GranularityPage    = "page"
+)
+
+// Record types stored in Document.Type.
+const (
+	TypeContent = "content"
+	TypePage    = "page"
+)
+
+// extractDocuments turns a parsed page into documents, using the selectors
+// for the hierarchy and the config for how records are cut.
+func extractDocuments(pageURL string, goDoc *goquery.Document, selectors *Selectors, config *Config) []Document {
+	var records []*record
+	for _, r := range extractRecords(goDoc, selectors, config.Code) {
+		// The page-level record has no level and only passes without a minimum.
+		if config.MinIndexedLevel > 0 && r.level < config.MinIndexedLevel {
+			continue
+		}
+		records = append(records, r)
+	}
+
+	var documents []Document
+	switch config.RecordGranularity {
+	case GranularityText:
+		documents = textDocuments(pageURL, records, config.Chunking)
+	case GranularityPage:
+		documents = pageDocuments(pageURL, records, config.Chunking)
+	default:
+		documents = sectionDocuments(pageURL, records, config.Chunking)
+	}
+
+	for i := range documents {
+		documents[i].Position = i
+	}
+
+	return documents
+}
+
+// sectionDocuments emits one document per heading holding all of its text.
+func sectionDocuments(pageURL string, records []*record, chunking ChunkingConfig) []Document {
+	documents := make([]Document, 0, len(records))
+	for _, r := range records {
+		docs := chunkedDocuments(pageURL, r.anchor, r.hierarchy, r.content, r.idSuffix, r.recordType(), chunking)
+		docs[0].Code = r.code
+		documents = append(documents, docs...)
+	}
+	return documents
+}
+
+// textDocuments emits one document per heading and one per text node, each
+// content document carrying the hierarchy of the heading it appears under.
+// Code blocks go on the heading document. Table rows come in page order,
+// between the text before and after their table.
+func textDocuments(pageURL string, records []*record, chunking ChunkingConfig) []Document {
+	var documents []Document
+	appendContent := func(r *record, text, contentType string) {
+		idSuffix := fmt.Sprintf("|%d", len(documents))
+		documents = append(documents, chunkedDocuments(pageURL, r.anchor, r.hierarchy, []string{text}, idSuffix, contentType, chunking)...)
+	}
+	appendRow := func(r *record) {
+		for _, text := range r.content {
+			appendContent(r, text, TypeTableRow)
+		}
+	}
+
+	for i := 0; i < len(records); i++ {
+		r := records[i]
+		// Rows whose section was left out have nothing to be placed in.
+		if r.row {
+			appendRow(r)
+			continue
+		}
+		// Text before the first heading has no heading document to hold its
+		// code, so it gets a document of its own.
+		if r.level >= 0 || len(r.code) > 0 {
+			doc := newDocument(pageURL, r.anchor, r.hierarchy, "", "")
+			doc.Type = r.recordType()
+			doc.Code = r.code
+			documents = append(documents, doc)
+		}
+
+		// The section's rows follow it as records of their own.
+		end := i + 1
+		for end < len(records) && records[end].row {
+			end++
+		}
+		rows := records[i+1 : end]
+		for k, text := range r.content {
+			for len(rows) > 0 && rows[0].at <= k {
+				appendRow(rows[0])
+				rows = rows[1:]
+			}
+			appendContent(r, text, TypeContent)
+		}
+		for _, row := range rows {
+			appendRow(row)
+		}
+		i = end - 1
+	}
+	return documents
+}
+
+// pageDocuments emits a single document with all text of the page, under the
+// lvl0 and the first lvl1 of the page.
+func pageDocuments(pageURL string, records []*record, chunking ChunkingConfig) []Document {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var h hierarchy
+	h[0] = records[0].hierarchy[0]
+	var content []string
+	var code []CodeBlock
+	for _, r := range records {
+		if h[1] == nil && r.hierarchy[1] != nil {
+			h[1] = r.hierarchy[1]
+		}
+		content = append(content, r.content...)
+		code = append(code, r.code...)
+	}
+
+	documents := chunkedDocuments(pageURL, "", h, content, "", TypePage, chunking)
+	documents[0].Code = code
+	return documents
+}
+
+// chunkedDocuments builds the documents for one record's content: a single
+// document when it fits the chunk size, or one per chunk otherwise, sharing
+// anchor and hierarchy and numbered with a chunk index in the objectID. It
+// always returns at least one document; callers attach code blocks to the
+// first.
+func chunkedDocuments(pageURL, anchor string, h hierarchy, parts []string, idSuffix, recordType string, chunking ChunkingConfig) []Document {
+	chunks := chunking.Chunk(parts)
+	if len(chunks) <= 1 {
+		doc := newDocument(pageURL, anchor, h, strings.Join(parts, " "), idSuffix)
+		doc.Type = recordType
+		return []Document{doc}
+	}
+
+	documents := make([]Document, 0, len(chunks))
+	for i, chunk := range chunks {
+		doc := newDocument(pageURL, anchor, h, chunk, idSuffix)
+		doc.ObjectID = fmt.Sprintf("%s-%d", doc.ObjectID, i)
+		doc.Type = recordType
+		index := i
+		doc.Chunk = &index
+		documents = append(documents, doc)
+	}
+	return documents
+}
+
+// recordType returns "lvlN" for heading records, "table_row" for table rows
+// and "content" for the page-level record.
+func (r *record) recordType() string {
+	if r.row {
+		return TypeTableRow
+	}
+	if r.level < 0 {
+		return TypeContent
+	}
+	return fmt.Sprintf("lvl%d", r.level)
+}
+
+// newDocument builds a document for the given anchor of a page. idSuffix
+// distinguishes several documents sharing the same URL.
+func newDocument(pageURL, anchor string, h hierarchy, content, idSuffix string) Document {
+	fullURL := pageURL
+	if anchor != "" {
+		fullURL = fmt.Sprintf("%s#%s", pageURL, anchor)
+	}
+
+	// Generate objectID
+	hash := sha256.Sum256([]byte(fullURL + idSuffix))
+	objectID := hex.EncodeToString(hash[:])
+
+	var contentPtr *string
+	if content != "" {
+		contentPtr = &content
+	}
+
+	return Document{
+		Anchor:             anchor,
+		Content:            contentPtr,
+		URL:                fullURL,
+		ObjectID:           objectID,
+		HierarchyLvl0:      h[0],
+		HierarchyLvl1:      h[1],
+		HierarchyLvl2:      h[2],
+		HierarchyLvl3:      h[3],
+		HierarchyLvl4:      h[4],
+		HierarchyLvl5:      h[5],
+		HierarchyLvl6:      h[6],
+		HierarchyRadioLvl0: nil,
+		HierarchyRadioLvl1: h[1],
+		HierarchyRadioLvl2: h[2],
+		HierarchyRadioLvl3: h[3],
+		HierarchyRadioLvl4: h[4],
+		HierarchyRadioLvl5: h[5],
+	}
+}
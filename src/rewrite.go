@@ -0,0 +1,115 @@
+package src
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// URL rewrite modes. The default (empty) mode appends ".html", which fetches
+// the static version of pages that are otherwise rendered with JavaScript.
+const (
+	RewriteNone     = "none"
+	RewriteSuffix   = "suffix"
+	RewriteRegex    = "regex"
+	RewriteTemplate = "template"
+
+	defaultRewriteSuffix = ".html"
+)
+
+// rewriteData is passed to URL rewrite templates.
+type rewriteData struct {
+	URL      string
+	Scheme   string
+	Host     string
+	Path     string
+	RawQuery string
+	Fragment string
+}
+
+// Validate checks that the rewrite configuration can be applied.
+func (c URLRewriteConfig) Validate() error {
+	switch c.Mode {
+	case "", RewriteNone, RewriteSuffix:
+		return nil
+	case RewriteRegex:
+		if c.Pattern == "" {
+			return fmt.Errorf("url_rewrite: regex mode requires a pattern")
+		}
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			return fmt.Errorf("url_rewrite: invalid pattern: %w", err)
+		}
+		return nil
+	case RewriteTemplate:
+		if c.Template == "" {
+			return fmt.Errorf("url_rewrite: template mode requires a template")
+		}
+		if _, err := template.New("url").Parse(c.Template); err != nil {
+			return fmt.Errorf("url_rewrite: invalid template: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("url_rewrite: unknown mode %q", c.Mode)
+	}
+}
+
+// RewriteURL returns the URL that is actually fetched for a page. The page URL
+// itself is still used for the documents built from it.
+func RewriteURL(pageURL string, rewrite URLRewriteConfig) (string, error) {
+	switch rewrite.Mode {
+	case RewriteNone:
+		return pageURL, nil
+
+	case "", RewriteSuffix:
+		suffix := rewrite.Suffix
+		if rewrite.Mode == "" || suffix == "" {
+			suffix = defaultRewriteSuffix
+		}
+		u, err := url.Parse(pageURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid page URL: %w", err)
+		}
+		// Directory-style URLs have no file name to add a suffix to.
+		if u.Path == "" || strings.HasSuffix(u.Path, "/") || strings.HasSuffix(u.Path, suffix) {
+			return pageURL, nil
+		}
+		u.Path += suffix
+		u.RawPath = ""
+		return u.String(), nil
+
+	case RewriteRegex:
+		re, err := regexp.Compile(rewrite.Pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid rewrite pattern: %w", err)
+		}
+		return re.ReplaceAllString(pageURL, rewrite.Replacement), nil
+
+	case RewriteTemplate:
+		tmpl, err := template.New("url").Parse(rewrite.Template)
+		if err != nil {
+			return "", fmt.Errorf("invalid rewrite template: %w", err)
+		}
+		u, err := url.Parse(pageURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid page URL: %w", err)
+		}
+		var b strings.Builder
+		err = tmpl.Execute(&b, rewriteData{
+			URL:      pageURL,
+			Scheme:   u.Scheme,
+			Host:     u.Host,
+			Path:     u.Path,
+			RawQuery: u.RawQuery,
+			Fragment: u.Fragment,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to execute rewrite template: %w", err)
+		}
+		return b.String(), nil
+
+	default:
+		return "", fmt.Errorf("unknown URL rewrite mode: %s", rewrite.Mode)
+	}
+}
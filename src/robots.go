@@ -0,0 +1,338 @@
+package src
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUserAgent identifies the scraper in robots.txt matching.
+const DefaultUserAgent = "meilisearch-scraper"
+
+// Robots holds the parsed rules of a robots.txt file.
+type Robots struct {
+	groups   []robotsGroup
+	Sitemaps []string
+}
+
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// ParseRobots parses a robots.txt body. Unknown directives are ignored.
+func ParseRobots(body []byte) *Robots {
+	robots := &Robots{}
+	var current *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				robots.groups = append(robots.groups, robotsGroup{})
+				current = &robots.groups[len(robots.groups)-1]
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: compileRobotsPattern(value),
+			})
+
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+
+		case "sitemap":
+			if value != "" {
+				robots.Sitemaps = append(robots.Sitemaps, value)
+			}
+		}
+	}
+
+	return robots
+}
+
+// compileRobotsPattern turns a robots.txt path pattern with "*" wildcards and
+// an optional "$" end anchor into a regular expression.
+func compileRobotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// rulesFor returns the rules and crawl delay that apply to userAgent: those of
+// the groups naming its product token, or else the "*" groups. Following
+// RFC 9309, the product token is compared case-insensitively as a whole.
+func (r *Robots) rulesFor(userAgent string) ([]robotsRule, time.Duration) {
+	token := productToken(userAgent)
+
+	named := false
+	for _, group := range r.groups {
+		if group.names(token) {
+			named = true
+			break
+		}
+	}
+
+	var rules []robotsRule
+	var delay time.Duration
+	for _, group := range r.groups {
+		if named && !group.names(token) || !named && !group.names("*") {
+			continue
+		}
+		rules = append(rules, group.rules...)
+		if group.crawlDelay > delay {
+			delay = group.crawlDelay
+		}
+	}
+
+	return rules, delay
+}
+
+// names reports whether the group lists agent.
+func (g robotsGroup) names(agent string) bool {
+	for _, a := range g.agents {
+		if a == agent {
+			return true
+		}
+	}
+	return false
+}
+
+// productToken returns the lowercased product token of a user agent, the
+// part before the first "/" or space, e.g. "mybot" for "MyBot/1.0 (+url)".
+func productToken(userAgent string) string {
+	userAgent = strings.TrimSpace(userAgent)
+	if i := strings.IndexAny(userAgent, "/ \t"); i >= 0 {
+		userAgent = userAgent[:i]
+	}
+	return strings.ToLower(userAgent)
+}
+
+// Allowed reports whether userAgent may fetch the given path (including the
+// query string). The longest matching rule wins, and Allow wins ties.
+func (r *Robots) Allowed(userAgent, path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+
+	rules, _ := r.rulesFor(userAgent)
+
+	allowed := true
+	matched := -1
+	for _, rule := range rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > matched || (rule.length == matched && rule.allow) {
+			matched = rule.length
+			allowed = rule.allow
+		}
+	}
+
+	return allowed
+}
+
+// CrawlDelay returns the Crawl-delay that applies to userAgent, or zero.
+func (r *Robots) CrawlDelay(userAgent string) time.Duration {
+	_, delay := r.rulesFor(userAgent)
+	return delay
+}
+
+// FetchRobots downloads and parses robots.txt for the host of siteURL. As
+// recommended by RFC 9309, a missing file (4xx) allows everything, while an
+// unreachable one (5xx or network error) disallows everything.
+func FetchRobots(siteURL string) (*Robots, error) {
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+
+	resp, err := DefaultClient.Get(robotsURL)
+	if err != nil {
+		return disallowAll(), fmt.Errorf("failed to fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return disallowAll(), fmt.Errorf("unexpected status code for robots.txt: %d", resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return &Robots{}, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	if err != nil {
+		return disallowAll(), fmt.Errorf("failed to read robots.txt: %w", err)
+	}
+
+	return ParseRobots(body), nil
+}
+
+func disallowAll() *Robots {
+	return ParseRobots([]byte("User-agent: *\nDisallow: /"))
+}
+
+// RobotsCache fetches robots.txt once per host and answers policy questions
+// for URLs on any host. A nil *RobotsCache allows everything.
+type RobotsCache struct {
+	userAgent string
+
+	mu     sync.Mutex
+	robots map[string]*Robots
+}
+
+func NewRobotsCache(userAgent string) *RobotsCache {
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	return &RobotsCache{
+		userAgent: userAgent,
+		robots:    make(map[string]*Robots),
+	}
+}
+
+// Get returns the robots.txt rules for the host of rawURL.
+func (c *RobotsCache) Get(rawURL string) *Robots {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &Robots{}
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if robots, ok := c.robots[origin]; ok {
+		return robots
+	}
+
+	robots, err := FetchRobots(origin)
+	if err != nil {
+		log.Printf("Treating %s as disallowed: %v", origin, err)
+	}
+	c.robots[origin] = robots
+
+	return robots
+}
+
+// Allowed reports whether rawURL may be fetched.
+func (c *RobotsCache) Allowed(rawURL string) bool {
+	if c == nil {
+		return true
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	return c.Get(rawURL).Allowed(c.userAgent, path)
+}
+
+// Delay returns the Crawl-delay for the host of rawURL, or zero.
+func (c *RobotsCache) Delay(rawURL string) time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.Get(rawURL).CrawlDelay(c.userAgent)
+}
+
+// Filter returns the URLs that may be fetched.
+func (c *RobotsCache) Filter(urls []URL) []URL {
+	if c == nil {
+		return urls
+	}
+
+	var allowed []URL
+	for _, u := range urls {
+		if c.Allowed(u.Loc) {
+			allowed = append(allowed, u)
+		}
+	}
+	return allowed
+}
+
+// DiscoverSitemaps returns the sitemaps a site root advertises in its
+// robots.txt, falling back to /sitemap.xml.
+func (c *RobotsCache) DiscoverSitemaps(siteURL string) []string {
+	var robots *Robots
+	if c != nil {
+		robots = c.Get(siteURL)
+	} else {
+		robots, _ = FetchRobots(siteURL)
+	}
+	if robots != nil && len(robots.Sitemaps) > 0 {
+		return robots.Sitemaps
+	}
+
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return nil
+	}
+	return []string{u.Scheme + "://" + u.Host + "/sitemap.xml"}
+}
+
+// IsSiteRoot reports whether rawURL points to the root of a site rather than
+// to a sitemap file.
+func IsSiteRoot(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return u.Host != "" && (u.Path == "" || u.Path == "/") && u.RawQuery == ""
+}
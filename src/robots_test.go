@@ -0,0 +1,173 @@
+package src
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRobotsAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		robots    string
+		userAgent string
+		path      string
+		want      bool
+	}{
+		{
+			name:      "empty file allows everything",
+			robots:    "",
+			userAgent: "meilisearch-scraper",
+			path:      "/docs/",
+			want:      true,
+		},
+		{
+			name:      "star group applies without a named group",
+			robots:    "User-agent: *\nDisallow: /private/\n",
+			userAgent: "meilisearch-scraper",
+			path:      "/private/page",
+			want:      false,
+		},
+		{
+			name:      "named group replaces star group",
+			robots:    "User-agent: *\nDisallow: /\n\nUser-agent: meilisearch-scraper\nDisallow: /private/\n",
+			userAgent: "meilisearch-scraper",
+			path:      "/docs/",
+			want:      true,
+		},
+		{
+			name:      "product token is matched case-insensitively",
+			robots:    "User-agent: MyBot\nDisallow: /\n",
+			userAgent: "mybot/2.1 (+https://example.com/bot)",
+			path:      "/docs/",
+			want:      false,
+		},
+		{
+			name:      "agent contained in the user agent does not match",
+			robots:    "User-agent: a\nDisallow: /\n\nUser-agent: scraper\nDisallow: /\n",
+			userAgent: "meilisearch-scraper",
+			path:      "/docs/",
+			want:      true,
+		},
+		{
+			name:      "browser user agent only matches its product token",
+			robots:    "User-agent: compatible\nDisallow: /\n",
+			userAgent: "Mozilla/5.0 (compatible; DocsBot/1.0)",
+			path:      "/docs/",
+			want:      true,
+		},
+		{
+			name:      "groups naming the agent are merged",
+			robots:    "User-agent: bot\nDisallow: /a/\n\nUser-agent: bot\nDisallow: /b/\n",
+			userAgent: "bot",
+			path:      "/b/page",
+			want:      false,
+		},
+		{
+			name:      "consecutive agents share a group",
+			robots:    "User-agent: other\nUser-agent: bot\nDisallow: /b/\n",
+			userAgent: "bot",
+			path:      "/b/page",
+			want:      false,
+		},
+		{
+			name:      "longest match wins",
+			robots:    "User-agent: *\nDisallow: /docs/\nAllow: /docs/public/\n",
+			userAgent: "bot",
+			path:      "/docs/public/page",
+			want:      true,
+		},
+		{
+			name:      "longer disallow wins over allow",
+			robots:    "User-agent: *\nAllow: /docs/\nDisallow: /docs/private/\n",
+			userAgent: "bot",
+			path:      "/docs/private/page",
+			want:      false,
+		},
+		{
+			name:      "allow wins ties",
+			robots:    "User-agent: *\nDisallow: /docs\nAllow: /docs\n",
+			userAgent: "bot",
+			path:      "/docs/page",
+			want:      true,
+		},
+		{
+			name:      "wildcard matches any characters",
+			robots:    "User-agent: *\nDisallow: /*.pdf\n",
+			userAgent: "bot",
+			path:      "/files/guide.pdf",
+			want:      false,
+		},
+		{
+			name:      "end anchor matches the end of the path",
+			robots:    "User-agent: *\nDisallow: /*.php$\n",
+			userAgent: "bot",
+			path:      "/index.php",
+			want:      false,
+		},
+		{
+			name:      "end anchor does not match a longer path",
+			robots:    "User-agent: *\nDisallow: /*.php$\n",
+			userAgent: "bot",
+			path:      "/index.php?page=2",
+			want:      true,
+		},
+		{
+			name:      "empty disallow allows everything",
+			robots:    "User-agent: *\nDisallow:\n",
+			userAgent: "bot",
+			path:      "/docs/",
+			want:      true,
+		},
+		{
+			name:      "comments are ignored",
+			robots:    "# rules\nUser-agent: * # everyone\nDisallow: /tmp/ # scratch\n",
+			userAgent: "bot",
+			path:      "/tmp/file",
+			want:      false,
+		},
+		{
+			name:      "robots.txt itself is always allowed",
+			robots:    "User-agent: *\nDisallow: /\n",
+			userAgent: "bot",
+			path:      "/robots.txt",
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			robots := ParseRobots([]byte(tt.robots))
+			if got := robots.Allowed(tt.userAgent, tt.path); got != tt.want {
+				t.Errorf("Allowed(%q, %q) = %v, want %v", tt.userAgent, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRobotsCrawlDelayAndSitemaps(t *testing.T) {
+	robots := ParseRobots([]byte("User-agent: *\nCrawl-delay: 2.5\n\nUser-agent: bot\nCrawl-delay: 1\n\nSitemap: https://example.com/sitemap.xml\n"))
+
+	if got, want := robots.CrawlDelay("other"), 2500*time.Millisecond; got != want {
+		t.Errorf("CrawlDelay(other) = %v, want %v", got, want)
+	}
+	if got, want := robots.CrawlDelay("Bot/1.0"), time.Second; got != want {
+		t.Errorf("CrawlDelay(Bot/1.0) = %v, want %v", got, want)
+	}
+	if len(robots.Sitemaps) != 1 || robots.Sitemaps[0] != "https://example.com/sitemap.xml" {
+		t.Errorf("Sitemaps = %v", robots.Sitemaps)
+	}
+}
+
+func TestProductToken(t *testing.T) {
+	tests := map[string]string{
+		"meilisearch-scraper":                   "meilisearch-scraper",
+		"MyBot/1.0":                             "mybot",
+		"  DocsBot (+https://example.com)":      "docsbot",
+		"Mozilla/5.0 (compatible; DocsBot/1.0)": "mozilla",
+	}
+	for userAgent, want := range tests {
+		if got := productToken(userAgent); got != want {
+			t.Errorf("productToken(%q) = %q, want %q", userAgent, got, want)
+		}
+	}
+}
@@ -1,49 +1,71 @@
 package src
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/xml"
 	"fmt"
-	"io"
 	"net/http"
-	"strings"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
-func FetchSitemap(url string) (*Sitemap, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
-	}
-	defer resp.Body.Close()
+// PageResult is the outcome of scraping a single page.
+type PageResult struct {
+	Documents   []Document
+	SelectorSet string
+	Removed     []RuleCount
+	Metadata    PageMetadata
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
+func ScrapePage(page URL, config *Config) (*PageResult, error) {
+	pageURL := page.Loc
 
-	body, err := io.ReadAll(resp.Body)
+	fetchURL, err := RewriteURL(pageURL, config.URLRewrite)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
-	var sitemap Sitemap
-	if err := xml.Unmarshal(body, &sitemap); err != nil {
-		return nil, fmt.Errorf("failed to parse sitemap XML: %w", err)
+	goDoc, err := FetchDocument(fetchURL)
+	if err != nil {
+		return nil, err
 	}
 
-	return &sitemap, nil
+	return scrapeDocument(page, goDoc, config), nil
 }
 
-func ScrapePage(pageURL string, config *Config) ([]Document, error) {
-	// Always use .html extension to get static content instead of JS-rendered version
-	fetchURL := pageURL
-	if !strings.HasSuffix(pageURL, ".html") {
-		fetchURL = pageURL + ".html"
+// scrapeDocument extracts the documents of a page that was already fetched.
+// Exclusion selectors remove elements from goDoc.
+func scrapeDocument(page URL, goDoc *goquery.Document, config *Config) *PageResult {
+	pageURL := page.Loc
+
+	// Metadata is read before exclusion selectors can remove parts of <head>.
+	metadata := extractMetadata(goDoc, pageURL)
+
+	removed := removeExcluded(goDoc, config.SelectorsExclude)
+
+	// Documents are keyed by the canonical URL if configured, so that pages
+	// reachable under several URLs are indexed once.
+	documentURL := pageURL
+	if config.UseCanonicalURL && metadata.Canonical != "" {
+		documentURL = metadata.Canonical
 	}
 
-	resp, err := http.Get(fetchURL)
+	custom := extractCustomFields(config.CustomFields, pageURL, goDoc)
+
+	setName, selectors := config.selectSelectors(pageURL, goDoc)
+	documents := extractDocuments(documentURL, goDoc, selectors, config)
+
+	applyPageMetadata(documents, page)
+	metadata.apply(documents)
+	for i := range documents {
+		documents[i].Custom = custom
+	}
+
+	return &PageResult{Documents: documents, SelectorSet: setName, Removed: removed, Metadata: metadata}
+}
+
+// FetchDocument downloads and parses the HTML at fetchURL. The document's
+// Url is the final URL after any redirects.
+func FetchDocument(fetchURL string) (*goquery.Document, error) {
+	resp, err := DefaultClient.Get(fetchURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch page: %w", err)
 	}
@@ -57,141 +79,45 @@ func ScrapePage(pageURL string, config *Config) ([]Document, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
+	goDoc.Url = resp.Request.URL
+
+	return goDoc, nil
+}
+
+// removeExcluded deletes every element matching one of the exclusion
+// selectors, so that no later selector sees them, and reports how many
+// elements each selector removed.
+func removeExcluded(goDoc *goquery.Document, selectors []string) []RuleCount {
+	removed := make([]RuleCount, 0, len(selectors))
+	for _, selector := range selectors {
+		matches := goDoc.Find(selector)
+		removed = append(removed, RuleCount{Pattern: selector, Removed: matches.Length()})
+		matches.Remove()
+	}
+	return removed
+}
+
+// applyPageMetadata copies sitemap metadata of the page onto its documents.
+func applyPageMetadata(documents []Document, page URL) {
+	var lastModified *int64
+	if t, ok := page.LastModTime(); ok {
+		unix := t.Unix()
+		lastModified = &unix
+	}
 
-	var documents []Document
-
-	// Extract global lvl0 if configured
-	var lvl0Value *string
-	if config.Selectors.Lvl0.Global {
-		text := goDoc.Find(config.Selectors.Lvl0.Selector).First().Text()
-		text = strings.TrimSpace(text)
-		if text == "" && config.Selectors.Lvl0.DefaultValue != "" {
-			text = config.Selectors.Lvl0.DefaultValue
-		}
-		if text != "" {
-			lvl0Value = &text
-		}
+	var priority *float64
+	if p, ok := page.PriorityValue(); ok {
+		priority = &p
 	}
 
-	// Extract hierarchy levels
-	var lvl1Value, lvl2Value, lvl3Value, lvl4Value, lvl5Value, lvl6Value *string
-
-	if config.Selectors.Lvl1 != "" {
-		text := goDoc.Find(config.Selectors.Lvl1).First().Text()
-		text = strings.TrimSpace(text)
-		if text != "" {
-			lvl1Value = &text
-		}
-
-		// Process each heading section
-		goDoc.Find(config.Selectors.Lvl2).Each(func(i int, s *goquery.Selection) {
-			anchor, _ := s.Attr("id")
-			if anchor == "" {
-				anchor = fmt.Sprintf("section_%d", i)
-			}
-
-			lvl2Text := strings.TrimSpace(s.Text())
-			if lvl2Text != "" {
-				lvl2Value = &lvl2Text
-			}
-
-			// Extract content for this section
-			var contentParts []string
-
-			// Get text content following this heading
-			s.NextUntil(config.Selectors.Lvl2).Each(func(j int, content *goquery.Selection) {
-				if config.Selectors.Text != "" {
-					content.Find(config.Selectors.Text).Each(func(k int, textNode *goquery.Selection) {
-						text := strings.TrimSpace(textNode.Text())
-						if text != "" {
-							contentParts = append(contentParts, text)
-						}
-					})
-				}
-			})
-
-			contentText := strings.Join(contentParts, " ")
-			var contentPtr *string
-			if contentText != "" {
-				contentPtr = &contentText
-			}
-
-			fullURL := pageURL
-			if anchor != "" {
-				fullURL = fmt.Sprintf("%s#%s", pageURL, anchor)
-			}
-
-			// Generate objectID
-			hash := sha256.Sum256([]byte(fullURL))
-			objectID := hex.EncodeToString(hash[:])
-
-			doc := Document{
-				Anchor:             anchor,
-				Content:            contentPtr,
-				URL:                fullURL,
-				ObjectID:           objectID,
-				HierarchyLvl0:      lvl0Value,
-				HierarchyLvl1:      lvl1Value,
-				HierarchyLvl2:      lvl2Value,
-				HierarchyLvl3:      lvl3Value,
-				HierarchyLvl4:      lvl4Value,
-				HierarchyLvl5:      lvl5Value,
-				HierarchyLvl6:      lvl6Value,
-				HierarchyRadioLvl0: nil,
-				HierarchyRadioLvl1: lvl1Value,
-				HierarchyRadioLvl2: lvl2Value,
-				HierarchyRadioLvl3: lvl3Value,
-				HierarchyRadioLvl4: lvl4Value,
-				HierarchyRadioLvl5: lvl5Value,
-			}
-
-			documents = append(documents, doc)
-		})
-
-		// If no lvl2 headings found, create at least one document for the page
-		if len(documents) == 0 {
-			var contentParts []string
-			if config.Selectors.Text != "" {
-				goDoc.Find(config.Selectors.Text).Each(func(i int, s *goquery.Selection) {
-					text := strings.TrimSpace(s.Text())
-					if text != "" {
-						contentParts = append(contentParts, text)
-					}
-				})
-			}
-
-			contentText := strings.Join(contentParts, " ")
-			var contentPtr *string
-			if contentText != "" {
-				contentPtr = &contentText
-			}
-
-			hash := sha256.Sum256([]byte(pageURL))
-			objectID := hex.EncodeToString(hash[:])
-
-			doc := Document{
-				Anchor:             "",
-				Content:            contentPtr,
-				URL:                pageURL,
-				ObjectID:           objectID,
-				HierarchyLvl0:      lvl0Value,
-				HierarchyLvl1:      lvl1Value,
-				HierarchyLvl2:      nil,
-				HierarchyLvl3:      nil,
-				HierarchyLvl4:      nil,
-				HierarchyLvl5:      nil,
-				HierarchyLvl6:      nil,
-				HierarchyRadioLvl0: nil,
-				HierarchyRadioLvl1: lvl1Value,
-				HierarchyRadioLvl2: nil,
-				HierarchyRadioLvl3: nil,
-				HierarchyRadioLvl4: nil,
-				HierarchyRadioLvl5: nil,
-			}
-
-			documents = append(documents, doc)
-		}
+	var lang *string
+	if l := page.Lang(); l != "" {
+		lang = &l
+	}
 
+	for i := range documents {
+		documents[i].LastModified = lastModified
+		documents[i].Priority = priority
+		documents[i].Lang = lang
 	}
-	return documents, nil
 }
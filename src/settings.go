@@ -0,0 +1,135 @@
+package src
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/meilisearch/meilisearch-go"
+)
+
+// DefaultIndexSettings are applied for every setting the config leaves
+// unset: DocSearch-style relevancy where higher hierarchy levels outrank
+// deeper ones and the content, one result per URL.
+func DefaultIndexSettings() IndexSettings {
+	distinct := "url"
+	return IndexSettings{
+		SearchableAttributes: []string{
+			"hierarchy_lvl0",
+			"hierarchy_lvl1",
+			"hierarchy_lvl2",
+			"hierarchy_lvl3",
+			"hierarchy_lvl4",
+			"hierarchy_lvl5",
+			"hierarchy_lvl6",
+			"content",
+			"code",
+		},
+		RankingRules:         []string{"words", "typo", "attribute", "proximity", "sort", "exactness"},
+		DistinctAttribute:    &distinct,
+		FilterableAttributes: []string{"type", "lang"},
+	}
+}
+
+// WithDefaults returns the settings with every unset setting taken from
+// DefaultIndexSettings.
+func (s IndexSettings) WithDefaults() IndexSettings {
+	defaults := DefaultIndexSettings()
+	if s.SearchableAttributes == nil {
+		s.SearchableAttributes = defaults.SearchableAttributes
+	}
+	if s.DisplayedAttributes == nil {
+		s.DisplayedAttributes = defaults.DisplayedAttributes
+	}
+	if s.RankingRules == nil {
+		s.RankingRules = defaults.RankingRules
+	}
+	if s.DistinctAttribute == nil {
+		s.DistinctAttribute = defaults.DistinctAttribute
+	}
+	if s.FilterableAttributes == nil {
+		s.FilterableAttributes = defaults.FilterableAttributes
+	}
+	if s.SortableAttributes == nil {
+		s.SortableAttributes = defaults.SortableAttributes
+	}
+	if s.Synonyms == nil {
+		s.Synonyms = defaults.Synonyms
+	}
+	if s.StopWords == nil {
+		s.StopWords = defaults.StopWords
+	}
+	return s
+}
+
+// Meilisearch converts the settings for the settings API. Unset settings are
+// left out and so keep their current value in the index.
+func (s IndexSettings) Meilisearch() *meilisearch.Settings {
+	return &meilisearch.Settings{
+		SearchableAttributes: s.SearchableAttributes,
+		DisplayedAttributes:  s.DisplayedAttributes,
+		RankingRules:         s.RankingRules,
+		DistinctAttribute:    s.DistinctAttribute,
+		FilterableAttributes: s.FilterableAttributes,
+		SortableAttributes:   s.SortableAttributes,
+		Synonyms:             s.Synonyms,
+		StopWords:            s.StopWords,
+	}
+}
+
+// SettingChange is a setting whose value in the index differs from the
+// configured one. Current and Desired are JSON encoded.
+type SettingChange struct {
+	Name    string
+	Current string
+	Desired string
+}
+
+// DiffSettings compares the configured settings with the current settings of
+// an index. Settings that are unset in desired are not compared. Attribute
+// lists whose order has no meaning in Meilisearch are compared as sets.
+func DiffSettings(current *meilisearch.Settings, desired IndexSettings) []SettingChange {
+	var changes []SettingChange
+	compare := func(name string, set bool, currentValue, desiredValue interface{}) {
+		if !set {
+			return
+		}
+		currentJSON, _ := json.Marshal(currentValue)
+		desiredJSON, _ := json.Marshal(desiredValue)
+		if string(currentJSON) != string(desiredJSON) {
+			changes = append(changes, SettingChange{Name: name, Current: string(currentJSON), Desired: string(desiredJSON)})
+		}
+	}
+
+	distinct := ""
+	if current.DistinctAttribute != nil {
+		distinct = *current.DistinctAttribute
+	}
+	desiredDistinct := ""
+	if desired.DistinctAttribute != nil {
+		desiredDistinct = *desired.DistinctAttribute
+	}
+
+	compare("searchableAttributes", desired.SearchableAttributes != nil, current.SearchableAttributes, desired.SearchableAttributes)
+	compare("displayedAttributes", desired.DisplayedAttributes != nil, current.DisplayedAttributes, desired.DisplayedAttributes)
+	compare("rankingRules", desired.RankingRules != nil, current.RankingRules, desired.RankingRules)
+	compare("distinctAttribute", desired.DistinctAttribute != nil, distinct, desiredDistinct)
+	compare("filterableAttributes", desired.FilterableAttributes != nil, sortedCopy(current.FilterableAttributes), sortedCopy(desired.FilterableAttributes))
+	compare("sortableAttributes", desired.SortableAttributes != nil, sortedCopy(current.SortableAttributes), sortedCopy(desired.SortableAttributes))
+	compare("synonyms", desired.Synonyms != nil, nonNilSynonyms(current.Synonyms), nonNilSynonyms(desired.Synonyms))
+	compare("stopWords", desired.StopWords != nil, sortedCopy(current.StopWords), sortedCopy(desired.StopWords))
+
+	return changes
+}
+
+func sortedCopy(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func nonNilSynonyms(synonyms map[string][]string) map[string][]string {
+	if synonyms == nil {
+		return map[string][]string{}
+	}
+	return synonyms
+}
@@ -0,0 +1,243 @@
+package src
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSitemapDepth limits how deep nested sitemap indexes are followed.
+const maxSitemapDepth = 5
+
+// FetchSitemap fetches a sitemap or sitemap index and returns the merged,
+// de-duplicated list of page URLs. Child sitemaps referenced from an index
+// are fetched recursively; sitemaps that were already visited are skipped.
+func FetchSitemap(url string) (*Sitemap, error) {
+	return FetchSitemaps([]string{url})
+}
+
+// FetchSitemaps fetches several sitemaps and merges their URLs like
+// FetchSitemap. It fails only if none of the sitemaps could be fetched; the
+// sitemaps and child sitemaps that failed are listed in Sitemap.Failed.
+func FetchSitemaps(urls []string) (*Sitemap, error) {
+	sitemap := &Sitemap{}
+	visited := make(map[string]bool)
+	seen := make(map[string]bool)
+
+	var lastErr error
+	fetched := 0
+	for _, url := range urls {
+		if err := fetchSitemap(url, 0, visited, seen, sitemap); err != nil {
+			if len(urls) > 1 {
+				log.Printf("Failed to fetch sitemap %s: %v", url, err)
+			}
+			sitemap.Failed = append(sitemap.Failed, url)
+			lastErr = err
+			continue
+		}
+		fetched++
+	}
+
+	if fetched == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+
+	return sitemap, nil
+}
+
+func fetchSitemap(url string, depth int, visited, seen map[string]bool, result *Sitemap) error {
+	if visited[url] {
+		return nil
+	}
+	visited[url] = true
+
+	body, err := fetchSitemapBody(url)
+	if err != nil {
+		return err
+	}
+
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(body, &root); err != nil {
+		return fmt.Errorf("failed to parse sitemap XML: %w", err)
+	}
+
+	switch root.XMLName.Local {
+	case "urlset":
+		var sitemap Sitemap
+		if err := xml.Unmarshal(body, &sitemap); err != nil {
+			return fmt.Errorf("failed to parse sitemap XML: %w", err)
+		}
+		for _, u := range sitemap.URLs {
+			if u.Loc == "" || seen[u.Loc] {
+				continue
+			}
+			seen[u.Loc] = true
+			result.URLs = append(result.URLs, u)
+		}
+
+	case "sitemapindex":
+		if depth >= maxSitemapDepth {
+			log.Printf("Skipping sitemap index %s: maximum nesting depth %d reached", url, maxSitemapDepth)
+			return nil
+		}
+
+		var index SitemapIndex
+		if err := xml.Unmarshal(body, &index); err != nil {
+			return fmt.Errorf("failed to parse sitemap index XML: %w", err)
+		}
+		children, fetched := 0, 0
+		for _, child := range index.Sitemaps {
+			if child.Loc == "" {
+				continue
+			}
+			children++
+			if err := fetchSitemap(child.Loc, depth+1, visited, seen, result); err != nil {
+				log.Printf("Failed to fetch child sitemap %s: %v", child.Loc, err)
+				result.Failed = append(result.Failed, child.Loc)
+				continue
+			}
+			fetched++
+		}
+		if children > 0 && fetched == 0 {
+			return fmt.Errorf("none of the %d child sitemaps of %s could be fetched", children, url)
+		}
+
+	default:
+		return fmt.Errorf("unexpected sitemap root element: %s", root.XMLName.Local)
+	}
+
+	return nil
+}
+
+func fetchSitemapBody(url string) ([]byte, error) {
+	resp, err := DefaultClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if isGzip(url, resp.Header.Get("Content-Type"), body) {
+		body, err = gunzip(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap: %w", err)
+		}
+	}
+
+	return body, nil
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// isGzip reports whether a sitemap body should be gzip-decompressed. Bodies
+// starting with the gzip magic bytes always are; a .gz extension or gzip
+// content type is trusted unless the body is already plain XML, which happens
+// when the server sent Content-Encoding: gzip and the HTTP client decoded it.
+func isGzip(url, contentType string, body []byte) bool {
+	if bytes.HasPrefix(body, gzipMagic) {
+		return true
+	}
+
+	path := url
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if strings.HasSuffix(path, ".gz") || strings.Contains(contentType, "gzip") {
+		return !bytes.HasPrefix(bytes.TrimSpace(body), []byte("<"))
+	}
+
+	return false
+}
+
+func gunzip(body []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+var lastModLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// ParseLastMod parses a W3C datetime as used by <lastmod>.
+func ParseLastMod(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range lastModLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid lastmod date: %q", value)
+}
+
+// LastModTime returns the parsed <lastmod> value, if present and valid.
+func (u URL) LastModTime() (time.Time, bool) {
+	if u.LastMod == "" {
+		return time.Time{}, false
+	}
+	t, err := ParseLastMod(u.LastMod)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// PriorityValue returns the parsed <priority> value, if present and valid.
+func (u URL) PriorityValue() (float64, bool) {
+	if u.Priority == "" {
+		return 0, false
+	}
+	priority, err := strconv.ParseFloat(strings.TrimSpace(u.Priority), 64)
+	if err != nil || priority < 0 || priority > 1 {
+		return 0, false
+	}
+	return priority, true
+}
+
+// Lang returns the hreflang of the alternate link that points at the URL
+// itself, which is how multilingual sitemaps declare a page's language.
+func (u URL) Lang() string {
+	for _, alt := range u.Alternates {
+		if alt.Href == u.Loc && alt.Hreflang != "" && alt.Hreflang != "x-default" {
+			return alt.Hreflang
+		}
+	}
+	return ""
+}
+
+// FilterModifiedSince returns the URLs modified at or after since. URLs
+// without a usable <lastmod> are always kept.
+func FilterModifiedSince(urls []URL, since time.Time) []URL {
+	var filtered []URL
+	for _, u := range urls {
+		if t, ok := u.LastModTime(); ok && t.Before(since) {
+			continue
+		}
+		filtered = append(filtered, u)
+	}
+	return filtered
+}
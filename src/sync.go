@@ -0,0 +1,136 @@
+package src
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Sync scopes: the indexed documents a sync may remove are those of the
+// scraped hosts, or only those of the scraped pages.
+const (
+	SyncScopeSite  = "site"
+	SyncScopePages = "pages"
+)
+
+// IndexedDocument identifies a document already stored in the index.
+type IndexedDocument struct {
+	ObjectID string `json:"objectID"`
+	URL      string `json:"url"`
+}
+
+// SyncScope decides which indexed documents belong to the part of the site a
+// run has scraped.
+type SyncScope struct {
+	mode   string
+	pages  map[string]bool
+	hosts  map[string]bool
+	failed map[string]bool
+}
+
+// NewSyncScope returns the scope of a run that scraped the given page URLs,
+// of which the failed ones could not be scraped. Documents of failed pages
+// are never in scope, so a transient error does not remove them.
+func NewSyncScope(mode string, scraped, failed []string) (*SyncScope, error) {
+	switch mode {
+	case SyncScopeSite, SyncScopePages:
+	default:
+		return nil, fmt.Errorf("unknown sync scope %q", mode)
+	}
+
+	s := &SyncScope{
+		mode:   mode,
+		pages:  make(map[string]bool),
+		hosts:  make(map[string]bool),
+		failed: make(map[string]bool),
+	}
+	for _, page := range scraped {
+		s.pages[pageOf(page)] = true
+		s.hosts[hostOf(page)] = true
+	}
+	for _, page := range failed {
+		s.failed[pageOf(page)] = true
+	}
+	return s, nil
+}
+
+// Contains reports whether a document URL is in scope.
+func (s *SyncScope) Contains(docURL string) bool {
+	page := pageOf(docURL)
+	if s.failed[page] {
+		return false
+	}
+	if s.mode == SyncScopePages {
+		return s.pages[page]
+	}
+	return s.hosts[hostOf(docURL)]
+}
+
+// pageOf strips the fragment from a document URL.
+func pageOf(docURL string) string {
+	page, _, _ := strings.Cut(docURL, "#")
+	return page
+}
+
+// hostOf returns the scheme and host of a URL.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// SyncPlan is the outcome of comparing a run with the index: how many
+// documents are new or replace an existing one, how many indexed documents
+// are in scope, and which of them were not produced again.
+type SyncPlan struct {
+	Added   int
+	Updated int
+	InScope int
+	Stale   []IndexedDocument
+}
+
+// StaleRatio returns the fraction of the indexed documents in scope that are
+// stale.
+func (p SyncPlan) StaleRatio() float64 {
+	if p.InScope == 0 {
+		return 0
+	}
+	return float64(len(p.Stale)) / float64(p.InScope)
+}
+
+// PlanSync compares the documents produced by a run with the documents that
+// were in the index before it.
+func PlanSync(produced, indexed []IndexedDocument, scope *SyncScope) SyncPlan {
+	existing := make(map[string]bool, len(indexed))
+	for _, doc := range indexed {
+		existing[doc.ObjectID] = true
+	}
+
+	var plan SyncPlan
+	current := make(map[string]bool, len(produced))
+	for _, doc := range produced {
+		if current[doc.ObjectID] {
+			continue
+		}
+		current[doc.ObjectID] = true
+		if existing[doc.ObjectID] {
+			plan.Updated++
+		} else {
+			plan.Added++
+		}
+	}
+
+	for _, doc := range indexed {
+		if !scope.Contains(doc.URL) {
+			continue
+		}
+		plan.InScope++
+		if !current[doc.ObjectID] {
+			plan.Stale = append(plan.Stale, doc)
+		}
+	}
+
+	return plan
+}
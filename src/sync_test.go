@@ -0,0 +1,119 @@
+package src
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSyncScopeContains(t *testing.T) {
+	scraped := []string{"https://docs.example.com/a", "https://docs.example.com/b"}
+	failed := []string{"https://docs.example.com/b"}
+
+	tests := []struct {
+		mode   string
+		docURL string
+		want   bool
+	}{
+		{SyncScopeSite, "https://docs.example.com/a#intro", true},
+		{SyncScopeSite, "https://docs.example.com/gone", true},
+		{SyncScopeSite, "https://other.example.com/a", false},
+		{SyncScopeSite, "http://docs.example.com/a", false},
+		{SyncScopeSite, "https://docs.example.com/b#section", false},
+		{SyncScopePages, "https://docs.example.com/a", true},
+		{SyncScopePages, "https://docs.example.com/a#intro", true},
+		{SyncScopePages, "https://docs.example.com/gone", false},
+		{SyncScopePages, "https://docs.example.com/b", false},
+	}
+
+	for _, tt := range tests {
+		scope, err := NewSyncScope(tt.mode, scraped, failed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := scope.Contains(tt.docURL); got != tt.want {
+			t.Errorf("%s scope: Contains(%q) = %v, want %v", tt.mode, tt.docURL, got, tt.want)
+		}
+	}
+}
+
+func TestNewSyncScopeInvalidMode(t *testing.T) {
+	if _, err := NewSyncScope("everything", nil, nil); err == nil {
+		t.Error("NewSyncScope() with an unknown mode returned no error")
+	}
+}
+
+func TestPlanSync(t *testing.T) {
+	indexed := []IndexedDocument{
+		{ObjectID: "a-intro", URL: "https://docs.example.com/a#intro"},
+		{ObjectID: "a-old", URL: "https://docs.example.com/a#renamed"},
+		{ObjectID: "gone", URL: "https://docs.example.com/gone"},
+		{ObjectID: "failed", URL: "https://docs.example.com/failed#usage"},
+		{ObjectID: "other", URL: "https://other.example.com/x"},
+	}
+	produced := []IndexedDocument{
+		{ObjectID: "a-intro", URL: "https://docs.example.com/a#intro"},
+		{ObjectID: "a-new", URL: "https://docs.example.com/a#new"},
+		{ObjectID: "a-new", URL: "https://docs.example.com/a#new"},
+	}
+	scraped := []string{"https://docs.example.com/a", "https://docs.example.com/failed"}
+	failed := []string{"https://docs.example.com/failed"}
+
+	tests := []struct {
+		mode  string
+		want  SyncPlan
+		ratio float64
+	}{
+		{
+			mode: SyncScopeSite,
+			want: SyncPlan{
+				Added:   1,
+				Updated: 1,
+				InScope: 3,
+				Stale: []IndexedDocument{
+					{ObjectID: "a-old", URL: "https://docs.example.com/a#renamed"},
+					{ObjectID: "gone", URL: "https://docs.example.com/gone"},
+				},
+			},
+			ratio: 2.0 / 3,
+		},
+		{
+			mode: SyncScopePages,
+			want: SyncPlan{
+				Added:   1,
+				Updated: 1,
+				InScope: 2,
+				Stale: []IndexedDocument{
+					{ObjectID: "a-old", URL: "https://docs.example.com/a#renamed"},
+				},
+			},
+			ratio: 0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			scope, err := NewSyncScope(tt.mode, scraped, failed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := PlanSync(produced, indexed, scope)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlanSync() = %+v, want %+v", got, tt.want)
+			}
+			if ratio := got.StaleRatio(); ratio != tt.ratio {
+				t.Errorf("StaleRatio() = %v, want %v", ratio, tt.ratio)
+			}
+		})
+	}
+}
+
+func TestPlanSyncEmptyIndex(t *testing.T) {
+	scope, err := NewSyncScope(SyncScopeSite, []string{"https://docs.example.com/a"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := PlanSync([]IndexedDocument{{ObjectID: "a", URL: "https://docs.example.com/a"}}, nil, scope)
+	if plan.Added != 1 || plan.Updated != 0 || len(plan.Stale) != 0 || plan.StaleRatio() != 0 {
+		t.Errorf("PlanSync() = %+v, want one added document", plan)
+	}
+}
@@ -0,0 +1,163 @@
+package src
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Table modes: flatten tables like any other text, or turn each row into
+// "header: value" text.
+const (
+	TableModeText = "text"
+	TableModeRows = "rows"
+)
+
+// TypeTableRow is the Document.Type of documents holding a single table row.
+const TypeTableRow = "table_row"
+
+// Validate checks the table mode and threshold.
+func (t TableConfig) Validate() error {
+	switch t.Mode {
+	case "", TableModeText, TableModeRows:
+	default:
+		return fmt.Errorf("unknown table mode %q", t.Mode)
+	}
+	if t.RecordThreshold < 0 {
+		return fmt.Errorf("table record_threshold must not be negative")
+	}
+	return nil
+}
+
+// rowRecords reports whether a table with the given number of rows gets one
+// record per row.
+func (t TableConfig) rowRecords(rows int) bool {
+	return t.RecordThreshold > 0 && rows >= t.RecordThreshold
+}
+
+// tableRow is the text of one body row and the id it can be linked to.
+type tableRow struct {
+	id   string
+	text string
+}
+
+// tableRows returns the body rows of a table as "header: value" pairs joined
+// by "; ", or as the cell texts joined by " | " when the table has no header
+// row. Header cells are taken from the last row of <thead>, or from the first
+// row when it only holds <th> cells. Text of the skipped elements is left out.
+func tableRows(table *goquery.Selection, skip map[*html.Node]bool) []tableRow {
+	tableNode := table.Get(0)
+	rows := table.Find("tr").FilterFunction(func(i int, s *goquery.Selection) bool {
+		return s.Closest("table").Get(0) == tableNode
+	})
+
+	// headers holds the header text of each column and headerStart the
+	// column its header cell starts in, so that the cells under a spanning
+	// header are reported together.
+	var headers []string
+	var headerStart []int
+	headerIndex := -1
+	rows.Each(func(i int, s *goquery.Selection) {
+		if s.Parent().Is("thead") {
+			headerIndex = i
+		}
+	})
+	if headerIndex < 0 && rows.Length() > 0 {
+		first := rows.First().Children()
+		if first.Length() > 0 && first.Length() == first.Filter("th").Length() {
+			headerIndex = 0
+		}
+	}
+	if headerIndex >= 0 {
+		for _, cell := range rowCells(rows.Eq(headerIndex), skip) {
+			for span := cell.span; span > 0; span-- {
+				headers = append(headers, cell.text)
+				headerStart = append(headerStart, cell.column)
+			}
+		}
+	}
+
+	var result []tableRow
+	rows.Each(func(i int, s *goquery.Selection) {
+		if i <= headerIndex || s.Parent().Is("thead") {
+			return
+		}
+
+		var parts []string
+		lastHeader := -1
+		for _, cell := range rowCells(s, skip) {
+			if cell.text == "" {
+				continue
+			}
+			if cell.column >= len(headers) || headers[cell.column] == "" {
+				parts = append(parts, cell.text)
+				lastHeader = -1
+				continue
+			}
+			if headerStart[cell.column] == lastHeader {
+				parts[len(parts)-1] += " " + cell.text
+				continue
+			}
+			parts = append(parts, headers[cell.column]+": "+cell.text)
+			lastHeader = headerStart[cell.column]
+		}
+		if len(parts) == 0 {
+			return
+		}
+
+		separator := " | "
+		if headers != nil {
+			separator = "; "
+		}
+		id, _ := s.Attr("id")
+		result = append(result, tableRow{id: id, text: strings.Join(parts, separator)})
+	})
+
+	return result
+}
+
+type tableCell struct {
+	text   string
+	column int
+	span   int
+}
+
+// rowCells returns the whitespace-normalised text of each cell of a row with
+// the column it starts in.
+func rowCells(row *goquery.Selection, skip map[*html.Node]bool) []tableCell {
+	var cells []tableCell
+	column := 0
+	row.Children().Filter("td, th").Each(func(i int, s *goquery.Selection) {
+		span, err := strconv.Atoi(s.AttrOr("colspan", "1"))
+		if err != nil || span < 1 {
+			span = 1
+		}
+		text := strings.Join(strings.Fields(textWithout(s.Get(0), skip)), " ")
+		cells = append(cells, tableCell{text: text, column: column, span: span})
+		column += span
+	})
+	return cells
+}
+
+// handledTable reports whether a table is inside (or is) a text match, not
+// inside a heading and not nested in another table, and therefore converted
+// row by row.
+func handledTable(node *html.Node, textNodes map[*html.Node]bool, levels map[*html.Node]int) bool {
+	inText := textNodes[node]
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if _, ok := levels[parent]; ok {
+			return false
+		}
+		if parent.Type == html.ElementNode && parent.DataAtom == atom.Table {
+			return false
+		}
+		if textNodes[parent] {
+			inText = true
+		}
+	}
+	return inText
+}
@@ -0,0 +1,151 @@
+package src
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+func TestTableRows(t *testing.T) {
+	tests := []struct {
+		name  string
+		table string
+		want  []tableRow
+	}{
+		{
+			name: "thead headers",
+			table: `<table>
+				<thead><tr><th>Name</th><th>Type</th></tr></thead>
+				<tbody><tr id="row-id"><td>id</td><td>int</td></tr><tr><td>name</td><td>string</td></tr></tbody>
+			</table>`,
+			want: []tableRow{{id: "row-id", text: "Name: id; Type: int"}, {text: "Name: name; Type: string"}},
+		},
+		{
+			name:  "first row of th cells is the header",
+			table: `<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td>2</td></tr></table>`,
+			want:  []tableRow{{text: "A: 1; B: 2"}},
+		},
+		{
+			name:  "row headers do not make a header row",
+			table: `<table><tr><th>Key</th><td>v1</td></tr><tr><th>Other</th><td>v2</td></tr></table>`,
+			want:  []tableRow{{text: "Key | v1"}, {text: "Other | v2"}},
+		},
+		{
+			name: "without headers cells are joined",
+			table: `<table><tr><td>x</td><td> spaced
+				out </td></tr></table>`,
+			want: []tableRow{{text: "x | spaced out"}},
+		},
+		{
+			name: "last thead row holds the headers",
+			table: `<table>
+				<thead><tr><th colspan="2">Group</th></tr><tr><th>A</th><th>B</th></tr></thead>
+				<tr><td>1</td><td>2</td></tr>
+			</table>`,
+			want: []tableRow{{text: "A: 1; B: 2"}},
+		},
+		{
+			name: "cells under a spanning header are reported together",
+			table: `<table>
+				<thead><tr><th colspan="2">Range</th><th>Unit</th></tr></thead>
+				<tr><td>1</td><td>5</td><td>ms</td></tr>
+			</table>`,
+			want: []tableRow{{text: "Range: 1 5; Unit: ms"}},
+		},
+		{
+			name: "spanning cell takes the header of its first column",
+			table: `<table>
+				<thead><tr><th>A</th><th>B</th><th>C</th></tr></thead>
+				<tr><td colspan="2">wide</td><td>c</td></tr>
+			</table>`,
+			want: []tableRow{{text: "A: wide; C: c"}},
+		},
+		{
+			name: "cells beyond the headers and empty cells",
+			table: `<table>
+				<thead><tr><th>A</th><th></th></tr></thead>
+				<tr><td>1</td><td>2</td><td>3</td></tr>
+				<tr><td>4</td><td></td></tr>
+				<tr><td></td><td></td></tr>
+			</table>`,
+			want: []tableRow{{text: "A: 1; 2; 3"}, {text: "A: 4"}},
+		},
+		{
+			name: "rows of nested tables are not rows of the outer table",
+			table: `<table>
+				<tr><th>Outer</th></tr>
+				<tr><td>x <table><tr><td>inner</td></tr></table></td></tr>
+			</table>`,
+			want: []tableRow{{text: "Outer: x inner"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.table))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := tableRows(doc.Find("table").First(), nil)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tableRows() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableRowsSkip(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(
+		`<table><tr><th>Name</th><th>Example</th></tr><tr><td>id</td><td>see <code>id = 1</code></td></tr></table>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	skip := map[*html.Node]bool{doc.Find("code").Get(0): true}
+
+	got := tableRows(doc.Find("table"), skip)
+	want := []tableRow{{text: "Name: id; Example: see"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tableRows() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTextDocumentsTableRowOrder(t *testing.T) {
+	goDoc := parseHTML(t, `<html><body>
+<h1>Limits</h1>
+<p>Before the table.</p>
+<table>
+<tr><th>Name</th><th>Value</th></tr>
+<tr><td>size</td><td>10</td></tr>
+<tr><td>depth</td><td>3</td></tr>
+</table>
+<p>After the table.</p>
+</body></html>`)
+
+	selectors := testSelectors
+	selectors.Text = "p, table"
+	selectors.Tables = TableConfig{Mode: TableModeRows, RecordThreshold: 1}
+	config := &Config{RecordGranularity: GranularityText}
+
+	var got []string
+	for _, doc := range extractDocuments(testPageURL, goDoc, &selectors, config) {
+		content := ""
+		if doc.Content != nil {
+			content = *doc.Content
+		}
+		got = append(got, doc.Type+": "+content)
+	}
+
+	want := []string{
+		"lvl1: ",
+		"content: Before the table.",
+		"table_row: Name: size; Value: 10",
+		"table_row: Name: depth; Value: 3",
+		"content: After the table.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got documents\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -5,23 +5,173 @@ import "encoding/xml"
 type Sitemap struct {
 	XMLName xml.Name `xml:"urlset"`
 	URLs    []URL    `xml:"url"`
+
+	// Failed lists the sitemaps that could not be fetched, when others could.
+	Failed []string `xml:"-"`
 }
 
-type URL struct {
+type SitemapIndex struct {
+	XMLName  xml.Name       `xml:"sitemapindex"`
+	Sitemaps []SitemapEntry `xml:"sitemap"`
+}
+
+type SitemapEntry struct {
 	Loc string `xml:"loc"`
 }
 
+type URL struct {
+	Loc        string      `xml:"loc"`
+	LastMod    string      `xml:"lastmod"`
+	ChangeFreq string      `xml:"changefreq"`
+	Priority   string      `xml:"priority"`
+	Alternates []Alternate `xml:"http://www.w3.org/1999/xhtml link"`
+}
+
+type Alternate struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
 type Config struct {
-	Selectors struct {
-		Lvl0 SelectorConfig `json:"lvl0"`
-		Lvl1 string         `json:"lvl1"`
-		Lvl2 string         `json:"lvl2"`
-		Lvl3 string         `json:"lvl3"`
-		Lvl4 string         `json:"lvl4"`
-		Lvl5 string         `json:"lvl5"`
-		Lvl6 string         `json:"lvl6"`
-		Text string         `json:"text"`
-	} `json:"selectors"`
+	Selectors        Selectors        `json:"selectors"`
+	SelectorSets     []SelectorSet    `json:"selector_sets"`
+	SelectorsExclude []string         `json:"selectors_exclude"`
+	Crawl            CrawlConfig      `json:"crawl"`
+	Robots           RobotsConfig     `json:"robots"`
+	URLs             URLRules         `json:"urls"`
+	URLRewrite       URLRewriteConfig `json:"url_rewrite"`
+	Concurrency      int              `json:"concurrency"`
+	RateLimit        float64          `json:"rate_limit"`
+	HTTP             HTTPConfig       `json:"http"`
+	Sitemaps         []string         `json:"sitemaps"`
+
+	UseCanonicalURL   bool                   `json:"use_canonical_url"`
+	MinIndexedLevel   int                    `json:"min_indexed_level"`
+	RecordGranularity string                 `json:"record_granularity"`
+	Chunking          ChunkingConfig         `json:"chunking"`
+	CustomFields      map[string]CustomField `json:"custom_fields"`
+	IndexSettings     IndexSettings          `json:"index_settings"`
+	Upload            UploadConfig           `json:"upload"`
+	Code              CodeConfig             `json:"code"`
+
+	// Set when the config was imported from another format.
+	Format    string `json:"-"`
+	IndexName string `json:"-"`
+}
+
+type HTTPConfig struct {
+	ConnectTimeout Duration `json:"connect_timeout"`
+	ReadTimeout    Duration `json:"read_timeout"`
+	Retries        *int     `json:"retries"`
+	BackoffBase    Duration `json:"backoff_base"`
+	BackoffMax     Duration `json:"backoff_max"`
+	UserAgent      string   `json:"user_agent"`
+
+	Headers        map[string]string `json:"headers"`
+	BasicAuth      *BasicAuthConfig  `json:"basic_auth"`
+	BearerToken    string            `json:"bearer_token"`
+	BearerTokenEnv string            `json:"bearer_token_env"`
+	AuthHosts      []string          `json:"auth_hosts"`
+	CookiesFile    string            `json:"cookies_file"`
+}
+
+type BasicAuthConfig struct {
+	Username    string `json:"username"`
+	UsernameEnv string `json:"username_env"`
+	Password    string `json:"password"`
+	PasswordEnv string `json:"password_env"`
+}
+
+type UploadConfig struct {
+	BatchSize  int `json:"batch_size"`
+	BatchBytes int `json:"batch_bytes"`
+}
+
+type IndexSettings struct {
+	SearchableAttributes []string            `json:"searchable_attributes"`
+	DisplayedAttributes  []string            `json:"displayed_attributes"`
+	RankingRules         []string            `json:"ranking_rules"`
+	DistinctAttribute    *string             `json:"distinct_attribute"`
+	FilterableAttributes []string            `json:"filterable_attributes"`
+	SortableAttributes   []string            `json:"sortable_attributes"`
+	Synonyms             map[string][]string `json:"synonyms"`
+	StopWords            []string            `json:"stop_words"`
+}
+
+type CustomField struct {
+	Selector   string `json:"selector"`
+	Attribute  string `json:"attribute"`
+	Meta       string `json:"meta"`
+	URLPattern string `json:"url_pattern"`
+	Group      *int   `json:"group"`
+	Value      any    `json:"value"`
+	Multiple   bool   `json:"multiple"`
+	Default    any    `json:"default"`
+}
+
+type ChunkingConfig struct {
+	MaxChars  int `json:"max_chars"`
+	MaxTokens int `json:"max_tokens"`
+	Overlap   int `json:"overlap"`
+}
+
+type CodeConfig struct {
+	Selector           string `json:"selector"`
+	ExcludeFromContent bool   `json:"exclude_from_content"`
+}
+
+type URLRewriteConfig struct {
+	Mode        string `json:"mode"`
+	Suffix      string `json:"suffix"`
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+	Template    string `json:"template"`
+}
+
+type URLRules struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+type RobotsConfig struct {
+	Ignore    bool   `json:"ignore"`
+	UserAgent string `json:"user_agent"`
+}
+
+type CrawlConfig struct {
+	StartURLs       []string `json:"start_urls"`
+	AllowedPrefixes []string `json:"allowed_prefixes"`
+	MaxDepth        int      `json:"max_depth"`
+	MaxPages        int      `json:"max_pages"`
+}
+
+type Selectors struct {
+	Lvl0 SelectorConfig `json:"lvl0"`
+	Lvl1 string         `json:"lvl1"`
+	Lvl2 string         `json:"lvl2"`
+	Lvl3 string         `json:"lvl3"`
+	Lvl4 string         `json:"lvl4"`
+	Lvl5 string         `json:"lvl5"`
+	Lvl6 string         `json:"lvl6"`
+	Text string         `json:"text"`
+
+	Tables TableConfig `json:"tables"`
+}
+
+type TableConfig struct {
+	Mode            string `json:"mode"`
+	RecordThreshold int    `json:"record_threshold"`
+}
+
+type SelectorSet struct {
+	Name        string    `json:"name"`
+	URLPatterns []string  `json:"url_patterns"`
+	Match       string    `json:"match"`
+	Selectors   Selectors `json:"selectors"`
+
+	// urlRules are the compiled URL patterns, set by compileURLPatterns.
+	urlRules []urlRule
 }
 
 type SelectorConfig struct {
@@ -31,21 +181,43 @@ type SelectorConfig struct {
 }
 
 type Document struct {
-	Anchor             string  `json:"anchor"`
-	Content            *string `json:"content"`
-	URL                string  `json:"url"`
-	ObjectID           string  `json:"objectID"`
-	HierarchyLvl0      *string `json:"hierarchy_lvl0"`
-	HierarchyLvl1      *string `json:"hierarchy_lvl1"`
-	HierarchyLvl2      *string `json:"hierarchy_lvl2"`
-	HierarchyLvl3      *string `json:"hierarchy_lvl3"`
-	HierarchyLvl4      *string `json:"hierarchy_lvl4"`
-	HierarchyLvl5      *string `json:"hierarchy_lvl5"`
-	HierarchyLvl6      *string `json:"hierarchy_lvl6"`
-	HierarchyRadioLvl0 *string `json:"hierarchy_radio_lvl0"`
-	HierarchyRadioLvl1 *string `json:"hierarchy_radio_lvl1"`
-	HierarchyRadioLvl2 *string `json:"hierarchy_radio_lvl2"`
-	HierarchyRadioLvl3 *string `json:"hierarchy_radio_lvl3"`
-	HierarchyRadioLvl4 *string `json:"hierarchy_radio_lvl4"`
-	HierarchyRadioLvl5 *string `json:"hierarchy_radio_lvl5"`
+	Anchor             string      `json:"anchor"`
+	Content            *string     `json:"content"`
+	Code               []CodeBlock `json:"code,omitempty"`
+	URL                string      `json:"url"`
+	ObjectID           string      `json:"objectID"`
+	HierarchyLvl0      *string     `json:"hierarchy_lvl0"`
+	HierarchyLvl1      *string     `json:"hierarchy_lvl1"`
+	HierarchyLvl2      *string     `json:"hierarchy_lvl2"`
+	HierarchyLvl3      *string     `json:"hierarchy_lvl3"`
+	HierarchyLvl4      *string     `json:"hierarchy_lvl4"`
+	HierarchyLvl5      *string     `json:"hierarchy_lvl5"`
+	HierarchyLvl6      *string     `json:"hierarchy_lvl6"`
+	HierarchyRadioLvl0 *string     `json:"hierarchy_radio_lvl0"`
+	HierarchyRadioLvl1 *string     `json:"hierarchy_radio_lvl1"`
+	HierarchyRadioLvl2 *string     `json:"hierarchy_radio_lvl2"`
+	HierarchyRadioLvl3 *string     `json:"hierarchy_radio_lvl3"`
+	HierarchyRadioLvl4 *string     `json:"hierarchy_radio_lvl4"`
+	HierarchyRadioLvl5 *string     `json:"hierarchy_radio_lvl5"`
+	Type               string      `json:"type"`
+	Position           int         `json:"position"`
+	Chunk              *int        `json:"chunk,omitempty"`
+	LastModified       *int64      `json:"last_modified,omitempty"`
+	Priority           *float64    `json:"priority,omitempty"`
+	Lang               *string     `json:"lang,omitempty"`
+
+	Title       *string           `json:"title,omitempty"`
+	Description *string           `json:"description,omitempty"`
+	Keywords    []string          `json:"keywords,omitempty"`
+	OpenGraph   map[string]string `json:"og,omitempty"`
+	Canonical   *string           `json:"canonical,omitempty"`
+
+	// Custom holds the custom_fields values, written next to the built-in
+	// fields by MarshalJSON.
+	Custom map[string]any `json:"-"`
+}
+
+type CodeBlock struct {
+	Language string `json:"language,omitempty"`
+	Content  string `json:"content"`
 }